@@ -1,7 +1,6 @@
 package uaa
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/url"
 )
@@ -10,29 +9,133 @@ type AllUsersInterface interface {
 	AllUsers() ([]User, error)
 }
 
+// Number of pages AllUsers fetches at once
+const DefaultAllUsersConcurrency = 4
+
 func AllUsers(u UAA) ([]User, error) {
-	var users []User
-	var totalResults int
-	var err error
+	return AllUsersWithConcurrency(u, DefaultAllUsersConcurrency)
+}
+
+// Fetches the first page of users, then the remaining pages with at most
+// concurrency requests in flight. Users are returned in UAA's order, up to
+// the first page that failed along with its error.
+//
+// Users created or deleted while the pages are fetched shift the pages. When
+// UAA reports a different totalResults on a later page, users listed twice
+// are dropped and the pages past the last user fetched are fetched one after
+// another, up to the largest total reported. Users deleted during the run may
+// still be missed, as with any paged listing.
+func AllUsersWithConcurrency(u UAA, concurrency int) (_ []User, err error) {
+	u, span := u.withOperation("AllUsers")
+	defer span.end(&err)
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	users, totalResults, err := PaginatedUsersFromQuery(u, u.uaaURL+"/Users")
+	if err != nil {
+		return users, err
+	}
+
+	pageSize := len(users)
+	if pageSize == 0 || !ThereAreMorePages(users, totalResults) {
+		return users, nil
+	}
 
-	bareUsersURL := u.uaaURL + "/Users"
-	users, totalResults, err = PaginatedUsersFromQuery(u, bareUsersURL)
+	var startIndexes []int
+	for startIndex := pageSize + 1; startIndex <= totalResults; startIndex += pageSize {
+		startIndexes = append(startIndexes, startIndex)
+	}
+
+	pages, err := fetchUserPages(u, startIndexes, pageSize, concurrency)
 	if err != nil {
+		for _, page := range pages {
+			if !page.fetched {
+				break
+			}
+			users = append(users, page.users...)
+		}
 		return users, err
 	}
 
-	for ThereAreMorePages(users, totalResults) {
-		var moreUsers []User
+	// Pages are fetched concurrently, so the last page is not necessarily
+	// the one reporting the latest total
+	consistent := true
+	latestTotalResults := totalResults
+	for _, page := range pages {
+		users = append(users, page.users...)
+		if page.totalResults != totalResults {
+			consistent = false
+		}
+		if page.totalResults > latestTotalResults {
+			latestTotalResults = page.totalResults
+		}
+	}
+
+	if consistent {
+		return users, nil
+	}
+
+	lastPage := pages[len(pages)-1]
+	nextStartIndex := startIndexes[len(startIndexes)-1] + len(lastPage.users)
+	return continueUsersAfterChange(u, users, latestTotalResults, nextStartIndex, pageSize)
+}
 
-		nextStartIndex := len(users) + 1
-		moreUsers, totalResults, err = PaginatedUsersFromQuery(u, UsersQueryURIFromStartIndex(u.uaaURL, nextStartIndex))
+type userPage struct {
+	users        []User
+	totalResults int
+	fetched      bool
+}
+
+// Fetches the pages starting at the given indexes with a pool of workers.
+// The first failure stops the pages that are still to be fetched, and is
+// returned along with the pages.
+func fetchUserPages(u UAA, startIndexes []int, pageSize, concurrency int) ([]userPage, error) {
+	pages := make([]userPage, len(startIndexes))
+
+	err := runConcurrently(u, len(startIndexes), concurrency, func(u UAA, i int) error {
+		users, totalResults, err := PaginatedUsersFromQuery(u, usersPageURI(u.uaaURL, startIndexes[i], pageSize))
 		if err != nil {
-			return users, err
+			return err
+		}
+
+		pages[i] = userPage{users: users, totalResults: totalResults, fetched: true}
+		return nil
+	})
+
+	return pages, err
+}
+
+// Drops users listed twice because of users created during the run, then
+// fetches the pages that were added past the original total
+func continueUsersAfterChange(u UAA, users []User, totalResults, nextStartIndex, pageSize int) ([]User, error) {
+	seen := map[string]bool{}
+	unique := []User{}
+	add := func(users []User) {
+		for _, user := range users {
+			if !seen[user.ID] {
+				seen[user.ID] = true
+				unique = append(unique, user)
+			}
 		}
-		users = append(users, moreUsers...)
+	}
+	add(users)
+
+	if nextStartIndex > totalResults {
+		return unique, nil
 	}
 
-	return users, nil
+	err := pageThrough(u, nextStartIndex, func(startIndex int) (int, int, error) {
+		page, totalResults, err := PaginatedUsersFromQuery(u, usersPageURI(u.uaaURL, startIndex, pageSize))
+		add(page)
+		return len(page), totalResults, err
+	})
+	return unique, err
+}
+
+func usersPageURI(host string, startIndex, count int) string {
+	return fmt.Sprintf("%s&count=%d", UsersQueryURIFromStartIndex(host, startIndex), count)
 }
 
 func PaginatedUsersFromQuery(u UAA, uriString string) ([]User, int, error) {
@@ -42,8 +145,7 @@ func PaginatedUsersFromQuery(u UAA, uriString string) ([]User, int, error) {
 		return []User{}, 0, err
 	}
 
-	host := uri.Scheme + "://" + uri.Host
-	client := NewClient(host, u.VerifySSL).WithAuthorizationToken(u.AccessToken)
+	client := u.newAdminClient(uri)
 	code, body, err := client.MakeRequest("GET", uri.RequestURI(), nil)
 	if err != nil {
 		return users, 0, err
@@ -53,24 +155,13 @@ func PaginatedUsersFromQuery(u UAA, uriString string) ([]User, int, error) {
 		return users, 0, NewFailure(code, body)
 	}
 
-	var response map[string]interface{}
-	err = json.Unmarshal(body, &response)
+	decoded := []userJSON{}
+	response, err := decodeListResponse(body, &decoded)
 	if err != nil {
-		return users, 0, err
-	}
-
-	resources := response["resources"].([]interface{})
-	for _, resource := range resources {
-		user, err := UserFromResource(resource.(map[string]interface{}))
-		if err != nil {
-			return users, 0, err
-		}
-		users = append(users, user)
+		return []User{}, 0, err
 	}
 
-	totalResults := int(response["totalResults"].(float64))
-
-	return users, totalResults, nil
+	return usersFromJSON(decoded), response.TotalResults, nil
 }
 
 func UsersQueryURIFromStartIndex(host string, startIndex int) string {
@@ -1,10 +1,16 @@
 package uaa_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pivotal-cf/uaa-sso-golang/uaa"
 
@@ -183,9 +189,12 @@ var _ = Describe("AllUsers", func() {
 					FamilyName: "Admin",
 					GivenName:  "Mister",
 				},
-				Emails:   []string{"why-email@example.com"},
-				Active:   true,
-				Verified: false,
+				Emails:    []string{"why-email@example.com"},
+				Active:    true,
+				Verified:  false,
+				Groups:    fixtureGroups,
+				Approvals: []uaa.Approval{},
+				Meta:      fixtureMeta,
 			}
 
 			user2 := uaa.User{
@@ -195,9 +204,12 @@ var _ = Describe("AllUsers", func() {
 					FamilyName: "Some",
 					GivenName:  "User",
 				},
-				Emails:   []string{"slayer@example.com"},
-				Active:   true,
-				Verified: false,
+				Emails:    []string{"slayer@example.com"},
+				Active:    true,
+				Verified:  false,
+				Groups:    fixtureGroups,
+				Approvals: []uaa.Approval{},
+				Meta:      fixtureMeta,
 			}
 
 			user3 := uaa.User{
@@ -207,9 +219,12 @@ var _ = Describe("AllUsers", func() {
 					FamilyName: "Other",
 					GivenName:  "User",
 				},
-				Emails:   []string{"the-yesman@example.com"},
-				Active:   true,
-				Verified: false,
+				Emails:    []string{"the-yesman@example.com"},
+				Active:    true,
+				Verified:  false,
+				Groups:    fixtureGroups,
+				Approvals: []uaa.Approval{},
+				Meta:      fixtureMeta,
 			}
 
 			user4 := uaa.User{
@@ -219,15 +234,271 @@ var _ = Describe("AllUsers", func() {
 					FamilyName: "Nada",
 					GivenName:  "Mister",
 				},
-				Emails:   []string{"my-example@example.com"},
-				Active:   true,
-				Verified: false,
+				Emails:    []string{"my-example@example.com"},
+				Active:    true,
+				Verified:  false,
+				Groups:    fixtureGroups,
+				Approvals: []uaa.Approval{},
+				Meta:      fixtureMeta,
 			}
 
 			Expect(users).To(ConsistOf(user1, user2, user3, user4))
 		})
 	})
 
+	Context("when the context is canceled partway through", func() {
+		var ctx context.Context
+		var cancel context.CancelFunc
+		var requestCount int
+
+		BeforeEach(func() {
+			requestCount = 0
+			ctx, cancel = context.WithCancel(context.Background())
+			fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requestCount += 1
+				if requestCount > 1 {
+					cancel()
+					<-req.Context().Done()
+					return
+				}
+
+				response, err := json.Marshal(map[string]interface{}{
+					"resources":    usersFirstPage,
+					"startIndex":   1,
+					"itemsPerPage": 3,
+					"totalResults": 6,
+					"schemas":      []string{"urn:scim:schemas:core:1.0"},
+				})
+				if err != nil {
+					panic(err)
+				}
+
+				w.WriteHeader(http.StatusOK)
+				w.Write(response)
+			}))
+			auth = uaa.NewUAA("http://uaa.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+		})
+
+		AfterEach(func() {
+			cancel()
+			fakeUAAServer.Close()
+		})
+
+		It("stops paginating and returns the context error", func() {
+			users, err := uaa.AllUsers(auth.WithContext(ctx))
+
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(users).To(HaveLen(3))
+			Expect(requestCount).To(Equal(2))
+		})
+	})
+
+	Context("when there are many pages", func() {
+		var mutex sync.Mutex
+		var inFlight, maxInFlight int
+		var startIndexes []string
+		var totalResults int
+		var failAtStartIndex string
+		var insertedAfter int
+		var release chan struct{}
+
+		BeforeEach(func() {
+			inFlight, maxInFlight = 0, 0
+			startIndexes = []string{}
+			totalResults = 10
+			failAtStartIndex = ""
+			insertedAfter = 0
+			release = make(chan struct{})
+
+			fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				query := req.URL.Query()
+				startIndex := 1
+				if query.Get("startIndex") != "" {
+					startIndex, _ = strconv.Atoi(query.Get("startIndex"))
+				}
+
+				mutex.Lock()
+				startIndexes = append(startIndexes, strconv.Itoa(startIndex))
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				total := totalResults
+				fail := strconv.Itoa(startIndex) == failAtStartIndex
+				inserted := insertedAfter
+				mutex.Unlock()
+
+				defer func() {
+					mutex.Lock()
+					inFlight--
+					mutex.Unlock()
+				}()
+
+				if startIndex > 1 {
+					select {
+					case <-release:
+					case <-time.After(20 * time.Millisecond):
+					}
+				}
+
+				if fail {
+					w.WriteHeader(http.StatusInternalServerError)
+					w.Write([]byte(`{"error":"server_error"}`))
+					return
+				}
+
+				count := 3
+				if query.Get("count") != "" {
+					count, _ = strconv.Atoi(query.Get("count"))
+				}
+				resources := []map[string]string{}
+				for i := startIndex; i < startIndex+count && i <= total; i++ {
+					// A user inserted after user-n shifts the following ones
+					id := fmt.Sprintf("user-%d", i)
+					if inserted > 0 && i == inserted+1 {
+						id = "user-new"
+					} else if inserted > 0 && i > inserted+1 {
+						id = fmt.Sprintf("user-%d", i-1)
+					}
+					resources = append(resources, map[string]string{"id": id})
+				}
+
+				response, err := json.Marshal(map[string]interface{}{
+					"resources":    resources,
+					"startIndex":   startIndex,
+					"itemsPerPage": len(resources),
+					"totalResults": total,
+				})
+				if err != nil {
+					panic(err)
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write(response)
+			}))
+			auth = uaa.NewUAA("http://uaa.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+		})
+
+		AfterEach(func() {
+			fakeUAAServer.Close()
+		})
+
+		ids := func(users []uaa.User) []string {
+			ids := []string{}
+			for _, user := range users {
+				ids = append(ids, user.ID)
+			}
+			return ids
+		}
+
+		It("fetches the remaining pages concurrently and keeps UAA's order", func() {
+			users, err := uaa.AllUsersWithConcurrency(auth, 2)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ids(users)).To(Equal([]string{
+				"user-1", "user-2", "user-3", "user-4", "user-5",
+				"user-6", "user-7", "user-8", "user-9", "user-10",
+			}))
+			Expect(startIndexes).To(ConsistOf("1", "4", "7", "10"))
+			Expect(maxInFlight).To(Equal(2))
+		})
+
+		It("fetches one page at a time with a concurrency of 1", func() {
+			users, err := uaa.AllUsersWithConcurrency(auth, 1)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(users).To(HaveLen(10))
+			Expect(startIndexes).To(Equal([]string{"1", "4", "7", "10"}))
+			Expect(maxInFlight).To(Equal(1))
+		})
+
+		It("returns the users before the failed page along with its failure", func() {
+			failAtStartIndex = "7"
+
+			users, err := uaa.AllUsersWithConcurrency(auth, 1)
+
+			Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+			Expect(err.(uaa.Failure).Code()).To(Equal(http.StatusInternalServerError))
+			Expect(ids(users)).To(Equal([]string{"user-1", "user-2", "user-3", "user-4", "user-5", "user-6"}))
+			Expect(startIndexes).To(Equal([]string{"1", "4", "7"}))
+		})
+
+		It("reports the failure rather than the cancellation it caused", func() {
+			failAtStartIndex = "10"
+
+			_, err := uaa.AllUsersWithConcurrency(auth, 3)
+
+			Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+		})
+
+		It("fetches the pages added while the users were listed, without duplicates", func() {
+			fakeUAAServer.Config.Handler = wrapHandler(fakeUAAServer.Config.Handler, func(req *http.Request) {
+				if req.URL.Query().Get("startIndex") == "4" {
+					mutex.Lock()
+					totalResults = 14
+					mutex.Unlock()
+				}
+			})
+
+			users, err := uaa.AllUsersWithConcurrency(auth, 1)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ids(users)).To(Equal([]string{
+				"user-1", "user-2", "user-3", "user-4", "user-5", "user-6", "user-7",
+				"user-8", "user-9", "user-10", "user-11", "user-12", "user-13", "user-14",
+			}))
+			Expect(startIndexes).To(Equal([]string{"1", "4", "7", "10", "13"}))
+		})
+
+		It("drops the users listed twice when a user is inserted mid-listing", func() {
+			fakeUAAServer.Config.Handler = wrapHandler(fakeUAAServer.Config.Handler, func(req *http.Request) {
+				if req.URL.Query().Get("startIndex") == "4" {
+					mutex.Lock()
+					insertedAfter = 1
+					totalResults = 11
+					mutex.Unlock()
+				}
+			})
+
+			users, err := uaa.AllUsersWithConcurrency(auth, 1)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ids(users)).To(Equal([]string{
+				"user-1", "user-2", "user-3", "user-4", "user-5",
+				"user-6", "user-7", "user-8", "user-9", "user-10",
+			}))
+			Expect(startIndexes).To(Equal([]string{"1", "4", "7", "10"}))
+		})
+
+		It("continues up to the latest total when the last page reports an older one", func() {
+			handler := fakeUAAServer.Config.Handler
+			lastPageServed := make(chan struct{})
+			fakeUAAServer.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch req.URL.Query().Get("startIndex") {
+				case "4":
+					select {
+					case <-lastPageServed:
+					case <-time.After(time.Second):
+					}
+					mutex.Lock()
+					totalResults = 14
+					mutex.Unlock()
+				case "10":
+					defer close(lastPageServed)
+				}
+				handler.ServeHTTP(w, req)
+			})
+
+			users, err := uaa.AllUsersWithConcurrency(auth, 3)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ids(users)).To(Equal([]string{
+				"user-1", "user-2", "user-3", "user-4", "user-5", "user-6", "user-7",
+				"user-8", "user-9", "user-10", "user-11", "user-12", "user-13", "user-14",
+			}))
+			Expect(startIndexes).To(ConsistOf("1", "4", "7", "10", "11", "14"))
+		})
+	})
+
 	Context("when UAA is not responding normally", func() {
 		BeforeEach(func() {
 			fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -252,3 +523,10 @@ var _ = Describe("AllUsers", func() {
 		})
 	})
 })
+
+func wrapHandler(handler http.Handler, before func(*http.Request)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		before(req)
+		handler.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,268 @@
+package uaa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (state CircuitState) String() string {
+	switch state {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(state))
+}
+
+// Returned instead of making a request while the circuit breaker is open,
+// or half-open with its probes already in flight
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (err CircuitOpenError) Error() string {
+	return fmt.Sprintf("UAA circuit breaker is open, retry after %s", err.RetryAfter)
+}
+
+// Configures when a CircuitBreaker trips and how it recovers
+type CircuitBreakerConfig struct {
+	// Trips the breaker after this many failures in a row
+	ConsecutiveFailures int
+
+	// Trips the breaker once this ratio of requests fails (0.0 to 1.0),
+	// as soon as at least MinimumRequests were made within the Interval
+	FailureRate     float64
+	MinimumRequests int
+
+	// Period after which the closed breaker forgets earlier requests,
+	// zero keeps counting until the breaker trips
+	Interval time.Duration
+
+	// How long the breaker stays open before letting probe requests through,
+	// defaults to 30 seconds
+	OpenTimeout time.Duration
+
+	// Number of successful probes needed to close a half-open breaker, defaults to 1
+	HalfOpenProbes int
+
+	// Called whenever the breaker changes state
+	OnStateChange func(from, to CircuitState)
+}
+
+// Fails requests fast while UAA is unavailable. A breaker is shared between
+// every Client and UAA it is given to, use NewCircuitBreaker to create one.
+// Transport errors and 5xx responses count as failures.
+type CircuitBreaker struct {
+	config CircuitBreakerConfig
+
+	mutex               sync.Mutex
+	state               CircuitState
+	openedAt            time.Time
+	windowStart         time.Time
+	requests            int
+	failures            int
+	consecutiveFailures int
+	probesInFlight      int
+	probeSuccesses      int
+
+	// Incremented on every state change, so that outcomes of requests
+	// reserved in an earlier state are not counted against the current one
+	generation uint64
+}
+
+// CircuitBreaker constructor, trips after 5 consecutive failures if no
+// threshold is configured
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.ConsecutiveFailures <= 0 && config.FailureRate <= 0 {
+		config.ConsecutiveFailures = 5
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.HalfOpenProbes <= 0 {
+		config.HalfOpenProbes = 1
+	}
+
+	return &CircuitBreaker{
+		config:      config,
+		windowStart: time.Now(),
+	}
+}
+
+// Returns the current state of the breaker
+func (breaker *CircuitBreaker) State() CircuitState {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	state := breaker.state
+	if state == CircuitOpen && breaker.openTimeoutElapsed(time.Now()) {
+		state = CircuitHalfOpen
+	}
+	return state
+}
+
+// Reserves a request, returning a CircuitOpenError if it may not be made.
+// The returned generation must be passed to done along with the outcome.
+func (breaker *CircuitBreaker) allow() (uint64, error) {
+	if breaker == nil {
+		return 0, nil
+	}
+
+	breaker.mutex.Lock()
+	now := time.Now()
+	var transition func()
+
+	if breaker.state == CircuitOpen {
+		if !breaker.openTimeoutElapsed(now) {
+			retryAfter := breaker.openedAt.Add(breaker.config.OpenTimeout).Sub(now)
+			breaker.mutex.Unlock()
+			return 0, CircuitOpenError{RetryAfter: retryAfter}
+		}
+		transition = breaker.setState(CircuitHalfOpen, now)
+	}
+
+	if breaker.state == CircuitHalfOpen {
+		if breaker.probesInFlight+breaker.probeSuccesses >= breaker.config.HalfOpenProbes {
+			// A failing probe opens the breaker for another OpenTimeout
+			retryAfter := breaker.config.OpenTimeout
+			breaker.mutex.Unlock()
+			notify(transition)
+			return 0, CircuitOpenError{RetryAfter: retryAfter}
+		}
+		breaker.probesInFlight++
+	}
+
+	generation := breaker.generation
+	breaker.mutex.Unlock()
+	notify(transition)
+	return generation, nil
+}
+
+// Records the outcome of a request reserved with allow. Outcomes of requests
+// reserved before the breaker last changed state are ignored: a request let
+// through while closed is no probe of the half-open breaker.
+func (breaker *CircuitBreaker) done(generation uint64, code int, err error) {
+	if breaker == nil {
+		return
+	}
+
+	breaker.mutex.Lock()
+	if generation != breaker.generation {
+		breaker.mutex.Unlock()
+		return
+	}
+
+	now := time.Now()
+	var transition func()
+
+	ignored := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+	failed := err != nil || code >= http.StatusInternalServerError
+
+	switch breaker.state {
+	case CircuitHalfOpen:
+		breaker.probesInFlight--
+		switch {
+		case ignored:
+		case failed:
+			transition = breaker.setState(CircuitOpen, now)
+		default:
+			breaker.probeSuccesses++
+			if breaker.probeSuccesses >= breaker.config.HalfOpenProbes {
+				transition = breaker.setState(CircuitClosed, now)
+			}
+		}
+	case CircuitClosed:
+		if ignored {
+			break
+		}
+
+		if breaker.config.Interval > 0 && now.Sub(breaker.windowStart) >= breaker.config.Interval {
+			breaker.resetCounts(now)
+		}
+
+		breaker.requests++
+		if failed {
+			breaker.failures++
+			breaker.consecutiveFailures++
+		} else {
+			breaker.consecutiveFailures = 0
+		}
+
+		if breaker.shouldTrip() {
+			transition = breaker.setState(CircuitOpen, now)
+		}
+	}
+
+	breaker.mutex.Unlock()
+	notify(transition)
+}
+
+func (breaker *CircuitBreaker) shouldTrip() bool {
+	config := breaker.config
+	if config.ConsecutiveFailures > 0 && breaker.consecutiveFailures >= config.ConsecutiveFailures {
+		return true
+	}
+
+	if config.FailureRate > 0 && breaker.requests > 0 && breaker.requests >= config.MinimumRequests {
+		return float64(breaker.failures)/float64(breaker.requests) >= config.FailureRate
+	}
+
+	return false
+}
+
+func (breaker *CircuitBreaker) openTimeoutElapsed(now time.Time) bool {
+	return now.Sub(breaker.openedAt) >= breaker.config.OpenTimeout
+}
+
+func (breaker *CircuitBreaker) resetCounts(now time.Time) {
+	breaker.windowStart = now
+	breaker.requests = 0
+	breaker.failures = 0
+	breaker.consecutiveFailures = 0
+}
+
+// Changes the state while holding the lock, returning the callback
+// notification to run once the lock is released
+func (breaker *CircuitBreaker) setState(state CircuitState, now time.Time) func() {
+	from := breaker.state
+	breaker.state = state
+	breaker.generation++
+	breaker.probesInFlight = 0
+	breaker.probeSuccesses = 0
+
+	switch state {
+	case CircuitOpen:
+		breaker.openedAt = now
+	case CircuitClosed:
+		breaker.resetCounts(now)
+	}
+
+	callback := breaker.config.OnStateChange
+	if callback == nil || from == state {
+		return nil
+	}
+	return func() {
+		callback(from, state)
+	}
+}
+
+func notify(transition func()) {
+	if transition != nil {
+		transition()
+	}
+}
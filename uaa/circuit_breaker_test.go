@@ -0,0 +1,231 @@
+package uaa_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CircuitBreaker", func() {
+	var server *httptest.Server
+	var client uaa.Client
+	var breaker *uaa.CircuitBreaker
+	var requestCount int
+	var responseCode int
+	var transitions [][]uaa.CircuitState
+
+	onStateChange := func(from, to uaa.CircuitState) {
+		transitions = append(transitions, []uaa.CircuitState{from, to})
+	}
+
+	BeforeEach(func() {
+		requestCount = 0
+		responseCode = http.StatusServiceUnavailable
+		transitions = [][]uaa.CircuitState{}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			requestCount += 1
+			w.WriteHeader(responseCode)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("with a consecutive failures threshold", func() {
+		BeforeEach(func() {
+			breaker = uaa.NewCircuitBreaker(uaa.CircuitBreakerConfig{
+				ConsecutiveFailures: 3,
+				OpenTimeout:         20 * time.Millisecond,
+				OnStateChange:       onStateChange,
+			})
+			client = uaa.NewClient(server.URL, false).WithCircuitBreaker(breaker)
+		})
+
+		It("trips after the given number of failures in a row and fails fast", func() {
+			for i := 0; i < 3; i++ {
+				code, _, err := client.MakeRequest("GET", "/Users", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(code).To(Equal(http.StatusServiceUnavailable))
+			}
+			Expect(breaker.State()).To(Equal(uaa.CircuitOpen))
+
+			_, _, err := client.MakeRequest("GET", "/Users", nil)
+
+			var openErr uaa.CircuitOpenError
+			Expect(errors.As(err, &openErr)).To(BeTrue())
+			Expect(openErr.RetryAfter).To(BeNumerically(">", 0))
+			Expect(requestCount).To(Equal(3))
+			Expect(transitions).To(Equal([][]uaa.CircuitState{{uaa.CircuitClosed, uaa.CircuitOpen}}))
+		})
+
+		It("does not trip when failures are interrupted by a success", func() {
+			client.MakeRequest("GET", "/Users", nil)
+			client.MakeRequest("GET", "/Users", nil)
+			responseCode = http.StatusNotFound
+			client.MakeRequest("GET", "/Users", nil)
+			responseCode = http.StatusServiceUnavailable
+			client.MakeRequest("GET", "/Users", nil)
+			client.MakeRequest("GET", "/Users", nil)
+
+			Expect(breaker.State()).To(Equal(uaa.CircuitClosed))
+			Expect(requestCount).To(Equal(5))
+		})
+
+		It("closes again after a successful probe in the half-open state", func() {
+			for i := 0; i < 3; i++ {
+				client.MakeRequest("GET", "/Users", nil)
+			}
+			time.Sleep(25 * time.Millisecond)
+			Expect(breaker.State()).To(Equal(uaa.CircuitHalfOpen))
+
+			responseCode = http.StatusOK
+			code, _, err := client.MakeRequest("GET", "/Users", nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(breaker.State()).To(Equal(uaa.CircuitClosed))
+			Expect(transitions).To(Equal([][]uaa.CircuitState{
+				{uaa.CircuitClosed, uaa.CircuitOpen},
+				{uaa.CircuitOpen, uaa.CircuitHalfOpen},
+				{uaa.CircuitHalfOpen, uaa.CircuitClosed},
+			}))
+		})
+
+		It("opens again when the probe fails", func() {
+			for i := 0; i < 3; i++ {
+				client.MakeRequest("GET", "/Users", nil)
+			}
+			time.Sleep(25 * time.Millisecond)
+
+			client.MakeRequest("GET", "/Users", nil)
+
+			Expect(breaker.State()).To(Equal(uaa.CircuitOpen))
+			Expect(requestCount).To(Equal(4))
+			Expect(transitions[len(transitions)-1]).To(Equal([]uaa.CircuitState{uaa.CircuitHalfOpen, uaa.CircuitOpen}))
+		})
+
+		It("ignores the outcome of a request let through before the breaker tripped", func() {
+			started := make(chan string)
+			release := map[string]chan struct{}{
+				"/Users/slow":  make(chan struct{}),
+				"/Users/probe": make(chan struct{}),
+			}
+			slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if wait, ok := release[req.URL.Path]; ok {
+					started <- req.URL.Path
+					<-wait
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer slowServer.Close()
+			client = uaa.NewClient(slowServer.URL, false).WithCircuitBreaker(breaker)
+
+			finished := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				client.MakeRequest("GET", "/Users/slow", nil)
+				close(finished)
+			}()
+			Eventually(started).Should(Receive(Equal("/Users/slow")))
+
+			for i := 0; i < 3; i++ {
+				client.MakeRequest("GET", "/Users", nil)
+			}
+			time.Sleep(25 * time.Millisecond)
+
+			probed := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				client.MakeRequest("GET", "/Users/probe", nil)
+				close(probed)
+			}()
+			Eventually(started).Should(Receive(Equal("/Users/probe")))
+
+			close(release["/Users/slow"])
+			Eventually(finished).Should(BeClosed())
+
+			Expect(breaker.State()).To(Equal(uaa.CircuitHalfOpen))
+			_, _, err := client.MakeRequest("GET", "/Users", nil)
+			Expect(err).To(Equal(uaa.CircuitOpenError{RetryAfter: 20 * time.Millisecond}))
+
+			close(release["/Users/probe"])
+			Eventually(probed).Should(BeClosed())
+
+			Expect(breaker.State()).To(Equal(uaa.CircuitClosed))
+			Expect(transitions).To(Equal([][]uaa.CircuitState{
+				{uaa.CircuitClosed, uaa.CircuitOpen},
+				{uaa.CircuitOpen, uaa.CircuitHalfOpen},
+				{uaa.CircuitHalfOpen, uaa.CircuitClosed},
+			}))
+		})
+
+		It("stops retrying once the breaker trips", func() {
+			client = client.WithRetryPolicy(uaa.RetryPolicy{MaxAttempts: 10})
+
+			_, _, err := client.MakeRequest("GET", "/Users", nil)
+
+			Expect(err).To(BeAssignableToTypeOf(uaa.CircuitOpenError{}))
+			Expect(requestCount).To(Equal(3))
+		})
+	})
+
+	Context("with a failure rate threshold", func() {
+		BeforeEach(func() {
+			breaker = uaa.NewCircuitBreaker(uaa.CircuitBreakerConfig{
+				FailureRate:     0.5,
+				MinimumRequests: 4,
+				OnStateChange:   onStateChange,
+			})
+			client = uaa.NewClient(server.URL, false).WithCircuitBreaker(breaker)
+		})
+
+		It("trips once enough requests were made and the rate is reached", func() {
+			responseCode = http.StatusOK
+			client.MakeRequest("GET", "/Users", nil)
+			responseCode = http.StatusInternalServerError
+			client.MakeRequest("GET", "/Users", nil)
+			responseCode = http.StatusOK
+			client.MakeRequest("GET", "/Users", nil)
+			Expect(breaker.State()).To(Equal(uaa.CircuitClosed))
+
+			responseCode = http.StatusInternalServerError
+			client.MakeRequest("GET", "/Users", nil)
+
+			Expect(breaker.State()).To(Equal(uaa.CircuitOpen))
+		})
+	})
+
+	Context("when configured on the UAA", func() {
+		It("is shared by every request the UAA makes", func() {
+			auth := uaa.NewUAA("", server.URL, "the-client-id", "the-client-secret", "my-special-token")
+			auth.CircuitBreaker = uaa.NewCircuitBreaker(uaa.CircuitBreakerConfig{ConsecutiveFailures: 2})
+
+			_, err := uaa.UserByID(auth, "some-id")
+			Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+			_, err = uaa.UsersByIDs(auth, "some-id")
+			Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+
+			_, err = uaa.AllUsers(auth)
+			Expect(err).To(BeAssignableToTypeOf(uaa.CircuitOpenError{}))
+			Expect(requestCount).To(Equal(2))
+		})
+	})
+
+	Describe("CircuitState", func() {
+		It("has a readable name", func() {
+			Expect(uaa.CircuitClosed.String()).To(Equal("closed"))
+			Expect(uaa.CircuitOpen.String()).To(Equal("open"))
+			Expect(uaa.CircuitHalfOpen.String()).To(Equal("half-open"))
+		})
+	})
+})
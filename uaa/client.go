@@ -1,11 +1,15 @@
 package uaa
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"sync"
+	"time"
 )
 
 var _client *http.Client
@@ -18,6 +22,33 @@ type Client struct {
 	BasicAuthPassword string
 	AccessToken       string
 	VerifySSL         bool
+	RetryPolicy       RetryPolicy
+	Hooks             Hooks
+	CircuitBreaker    *CircuitBreaker
+	Logger            Logger
+	Metrics           Metrics
+	Tracer            Tracer
+
+	// Identity zone the request switches to, sent as the X-Identity-Zone-Id
+	// or X-Identity-Zone-Subdomain header
+	IdentityZoneID        string
+	IdentityZoneSubdomain string
+
+	// Additional headers sent with every request, overriding the defaults
+	Header http.Header
+
+	// Name of the UAA operation requests are reported under, like "UserByID"
+	Operation string
+
+	// Marks requests as safe to repeat whatever their method, for example
+	// client_credentials token requests
+	Idempotent bool
+
+	// Sends the requests instead of the shared client returned by GetClient
+	HTTPClient *http.Client
+
+	ctx         context.Context
+	traceParent string
 }
 
 func NewClient(host string, verifySSL bool) Client {
@@ -41,6 +72,83 @@ func (client Client) WithAuthorizationToken(token string) Client {
 	return client
 }
 
+// Returns a copy of the client that sends the given header with every request
+func (client Client) WithHeader(name, value string) Client {
+	header := http.Header{}
+	for key, values := range client.Header {
+		header[key] = values
+	}
+	header.Set(name, value)
+	client.Header = header
+	return client
+}
+
+func (client Client) WithIdentityZone(zoneID, subdomain string) Client {
+	client.IdentityZoneID = zoneID
+	client.IdentityZoneSubdomain = subdomain
+	return client
+}
+
+func (client Client) WithRetryPolicy(policy RetryPolicy) Client {
+	client.RetryPolicy = policy
+	return client
+}
+
+func (client Client) WithHooks(hooks Hooks) Client {
+	client.Hooks = hooks
+	return client
+}
+
+func (client Client) WithCircuitBreaker(breaker *CircuitBreaker) Client {
+	client.CircuitBreaker = breaker
+	return client
+}
+
+func (client Client) WithLogger(logger Logger) Client {
+	client.Logger = logger
+	return client
+}
+
+func (client Client) WithMetrics(metrics Metrics) Client {
+	client.Metrics = metrics
+	return client
+}
+
+func (client Client) WithTracer(tracer Tracer) Client {
+	client.Tracer = tracer
+	return client
+}
+
+func (client Client) WithHTTPClient(httpClient *http.Client) Client {
+	client.HTTPClient = httpClient
+	return client
+}
+
+func (client Client) WithOperation(operation string) Client {
+	client.Operation = operation
+	return client
+}
+
+// Returns a copy of the client whose requests may be retried whatever their method
+func (client Client) AsIdempotent() Client {
+	client.Idempotent = true
+	return client
+}
+
+// Returns a copy of the client whose requests are bound to the given context
+func (client Client) WithContext(ctx context.Context) Client {
+	client.ctx = ctx
+	return client
+}
+
+// Returns the context requests are made with, defaulting to context.Background
+func (client Client) Context() context.Context {
+	if client.ctx == nil {
+		return context.Background()
+	}
+	return client.ctx
+}
+
 func GetClient(client Client) *http.Client {
 	mutex.Lock()
 	defer mutex.Unlock()
@@ -59,10 +167,112 @@ func GetClient(client Client) *http.Client {
 
 // Make request with the given basic auth and ssl settings, returns reponse code and body as a byte array
 func (client Client) MakeRequest(method, path string, requestBody io.Reader) (int, []byte, error) {
+	return client.MakeRequestWithContext(client.Context(), method, path, requestBody)
+}
+
+// Same as MakeRequest, but the request is canceled when the given context is done.
+// Transient failures are retried according to the client's RetryPolicy, and
+// no attempt is made while the client's CircuitBreaker is open. The request
+// carries a W3C traceparent header for the span the client's Tracer starts,
+// or for the span context carried by ctx. When ctx carries the span of a UAA
+// operation, the request span is started as its child.
+func (client Client) MakeRequestWithContext(ctx context.Context, method, path string, requestBody io.Reader) (int, []byte, error) {
+	ctx, span, traceParent := client.startSpan(ctx, method, path)
+	client.traceParent = traceParent
+
+	start := time.Now()
+	code, body, err := client.makeRequest(ctx, method, path, requestBody)
+	if client.Metrics != nil {
+		client.Metrics.ObserveRequest(client.operationName(), StatusClass(code, err), time.Since(start))
+	}
+	if span != nil {
+		span.End(code, err)
+	}
+	operationSpanFromContext(ctx).observe(code)
+
+	return code, body, err
+}
+
+// Makes a request with requestBody encoded as JSON, unless it is nil, and
+// decodes the JSON response into responseBody, unless it is nil. Responses
+// with an error status code are returned as a Failure.
+func (client Client) MakeJSONRequest(method, path string, requestBody, responseBody interface{}) error {
+	var body io.Reader
+	if requestBody != nil {
+		encoded, err := json.Marshal(requestBody)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	client = client.WithHeader("Content-Type", "application/json").WithHeader("Accept", "application/json")
+	code, responseJSON, err := client.MakeRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+
+	if code > 399 {
+		return NewFailure(code, responseJSON)
+	}
+
+	if responseBody == nil || len(responseJSON) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(responseJSON, responseBody)
+}
+
+func (client Client) makeRequest(ctx context.Context, method, path string, requestBody io.Reader) (int, []byte, error) {
+	var payload []byte
+	if requestBody != nil {
+		var err error
+		payload, err = ioutil.ReadAll(requestBody)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		generation, err := client.CircuitBreaker.allow()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		code, body, header, err := client.makeAttempt(ctx, method, path, payload, attempt)
+		client.CircuitBreaker.done(generation, code, err)
+
+		if attempt >= client.RetryPolicy.MaxAttempts || !client.shouldRetry(method, code, err) {
+			return code, body, err
+		}
+
+		delay := client.RetryPolicy.delay(attempt, code, header)
+		client.Hooks.onRetry(RetryEvent{
+			Method:     method,
+			Path:       path,
+			Attempt:    attempt + 1,
+			StatusCode: code,
+			Err:        err,
+			Delay:      delay,
+		})
+
+		err = sleep(ctx, delay)
+		if err != nil {
+			return code, body, err
+		}
+	}
+}
+
+func (client Client) makeAttempt(ctx context.Context, method, path string, payload []byte, attempt int) (int, []byte, http.Header, error) {
+	var requestBody io.Reader
+	if payload != nil {
+		requestBody = bytes.NewReader(payload)
+	}
+
 	url := client.Host + path
-	request, err := http.NewRequest(method, url, requestBody)
+	request, err := http.NewRequestWithContext(ctx, method, url, requestBody)
 	if err != nil {
-		return 0, nil, err
+		return 0, nil, nil, err
 	}
 	if client.BasicAuthUsername != "" {
 		request.SetBasicAuth(client.BasicAuthUsername, client.BasicAuthPassword)
@@ -70,19 +280,52 @@ func (client Client) MakeRequest(method, path string, requestBody io.Reader) (in
 		request.Header.Set("Authorization", "Bearer "+client.AccessToken)
 	}
 	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if client.IdentityZoneID != "" {
+		request.Header.Set("X-Identity-Zone-Id", client.IdentityZoneID)
+	}
+	if client.IdentityZoneSubdomain != "" {
+		request.Header.Set("X-Identity-Zone-Subdomain", client.IdentityZoneSubdomain)
+	}
+	for name, values := range client.Header {
+		request.Header[name] = values
+	}
+	if client.traceParent != "" {
+		request.Header.Set("traceparent", client.traceParent)
+	}
 
-	httpClient := GetClient(client)
+	start := time.Now()
+	code, body, header, err := client.send(request)
+	if client.Logger != nil {
+		client.Logger.LogRequest(newRequestLog(client.operationName(), request, payload, attempt, code, header, body, time.Since(start), err))
+	}
+
+	return code, body, header, err
+}
+
+func (client Client) send(request *http.Request) (int, []byte, http.Header, error) {
+	httpClient := client.HTTPClient
+	if httpClient == nil {
+		httpClient = GetClient(client)
+	}
 	response, err := httpClient.Do(request)
 	if err != nil {
-		return 0, nil, err
+		return 0, nil, nil, err
 	}
+	defer response.Body.Close()
 
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return response.StatusCode, body, err
+		return response.StatusCode, body, response.Header, err
 	}
 
-	return response.StatusCode, body, nil
+	return response.StatusCode, body, response.Header, nil
+}
+
+func (client Client) operationName() string {
+	if client.Operation == "" {
+		return "Request"
+	}
+	return client.Operation
 }
 
 func (client Client) TLSConfig() *tls.Config {
@@ -0,0 +1,86 @@
+package uaa
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// UAA lets a client have two secrets at once, so secrets can be rotated
+// without downtime:
+//
+//  1. AddOAuthClientSecret registers the new secret next to the old one
+//  2. every instance is rolled to the new secret, for instance through
+//     WithClientSecrets(newSecret, oldSecret)
+//  3. DeleteOAuthClientSecret removes the old secret
+type ClientSecretRotationInterface interface {
+	AddOAuthClientSecret(string, string) error
+	DeleteOAuthClientSecret(string) error
+}
+
+// Adds a secondary secret to the client, both secrets are accepted until
+// DeleteOAuthClientSecret is called. Fails when the client already has two.
+func AddOAuthClientSecret(u UAA, id, secret string) (err error) {
+	u, span := u.withOperation("AddOAuthClientSecret")
+	defer span.end(&err)
+
+	change := clientSecretChange{
+		ClientID:   id,
+		Secret:     secret,
+		ChangeMode: "ADD",
+	}
+	return u.adminRequest("PUT", "/oauth/clients/"+url.PathEscape(id)+"/secret", nil, change, nil)
+}
+
+// Removes the older of the two secrets of the client
+func DeleteOAuthClientSecret(u UAA, id string) (err error) {
+	u, span := u.withOperation("DeleteOAuthClientSecret")
+	defer span.end(&err)
+
+	change := clientSecretChange{
+		ClientID:   id,
+		ChangeMode: "DELETE",
+	}
+	return u.adminRequest("PUT", "/oauth/clients/"+url.PathEscape(id)+"/secret", nil, change, nil)
+}
+
+// Returns a copy of the UAA authenticating with the first secret, and
+// falling back to the others in order while UAA rejects it
+func (u UAA) WithClientSecrets(secret string, fallbacks ...string) UAA {
+	u.ClientSecret = secret
+	u.FallbackClientSecrets = fallbacks
+	return u
+}
+
+func (u UAA) clientSecrets() []string {
+	secrets := []string{u.ClientSecret}
+	for _, secret := range u.FallbackClientSecrets {
+		if secret != "" && secret != u.ClientSecret {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+// Makes a token request authenticated as the client, trying the next client
+// secret for as long as UAA answers 401. UAA checks the client credentials
+// before anything else, so a rejected request has no side effects.
+func (u UAA) makeClientAuthenticatedRequest(uri *url.URL, params url.Values, idempotent bool) (int, []byte, error) {
+	var code int
+	var body []byte
+	var err error
+
+	for _, secret := range u.clientSecrets() {
+		client := u.newClient(uri).WithBasicAuthCredentials(u.ClientID, secret)
+		if idempotent {
+			client = client.AsIdempotent()
+		}
+
+		code, body, err = client.MakeRequest("POST", uri.RequestURI(), strings.NewReader(params.Encode()))
+		if err != nil || code != http.StatusUnauthorized {
+			return code, body, err
+		}
+	}
+
+	return code, body, err
+}
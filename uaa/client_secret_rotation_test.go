@@ -0,0 +1,110 @@
+package uaa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client secret rotation", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var secretsTried []string
+	var requestBodies []string
+
+	BeforeEach(func() {
+		secretsTried = []string{}
+		requestBodies = []string{}
+		fakeUAAServer = httptest.NewServer(recordRequests(nil, &requestBodies, func(w http.ResponseWriter, req *http.Request) {
+			switch req.URL.Path {
+			case "/oauth/token":
+				_, secret, _ := req.BasicAuth()
+				secretsTried = append(secretsTried, secret)
+				if secret != "current-secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write([]byte(`{"error":"unauthorized","error_description":"Bad credentials"}`))
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"access_token":"client-access-token","token_type":"bearer"}`))
+			case "/oauth/clients/my-app/secret":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"status":"ok","message":"Secret is added"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "my-app", "stale-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	It("adds a secondary secret to the client", func() {
+		err := uaa.AddOAuthClientSecret(auth, "my-app", "new-secret")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requestBodies[0]).To(MatchJSON(`{"clientId":"my-app","secret":"new-secret","changeMode":"ADD"}`))
+	})
+
+	It("deletes the old secret of the client", func() {
+		err := uaa.DeleteOAuthClientSecret(auth, "my-app")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requestBodies[0]).To(MatchJSON(`{"clientId":"my-app","changeMode":"DELETE"}`))
+	})
+
+	Describe("WithClientSecrets", func() {
+		It("sets the client secret and its fallbacks", func() {
+			rotated := auth.WithClientSecrets("new-secret", "old-secret")
+
+			Expect(rotated.ClientSecret).To(Equal("new-secret"))
+			Expect(rotated.FallbackClientSecrets).To(Equal([]string{"old-secret"}))
+			Expect(auth.ClientSecret).To(Equal("stale-secret"))
+		})
+	})
+
+	Context("with fallback client secrets", func() {
+		BeforeEach(func() {
+			auth = auth.WithClientSecrets("stale-secret", "stale-secret", "current-secret", "unused-secret")
+		})
+
+		It("falls back through the secrets when getting client tokens", func() {
+			token, err := uaa.GetClientToken(auth)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(token.Access).To(Equal("client-access-token"))
+			Expect(secretsTried).To(Equal([]string{"stale-secret", "current-secret"}))
+		})
+
+		It("falls back through the secrets when exchanging codes", func() {
+			token, err := uaa.Exchange(auth, "the-code")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(token.Access).To(Equal("client-access-token"))
+			Expect(secretsTried).To(Equal([]string{"stale-secret", "current-secret"}))
+			Expect(requestBodies[1]).To(ContainSubstring("code=the-code"))
+		})
+
+		It("returns the last rejection when no secret is accepted", func() {
+			auth = auth.WithClientSecrets("stale-secret", "older-secret")
+
+			_, err := uaa.GetClientToken(auth)
+
+			Expect(uaa.IsUnauthorized(err)).To(BeTrue())
+			Expect(secretsTried).To(Equal([]string{"stale-secret", "older-secret"}))
+		})
+	})
+
+	It("does not fall back without fallback secrets", func() {
+		_, err := uaa.GetClientToken(auth)
+
+		Expect(uaa.IsUnauthorized(err)).To(BeTrue())
+		Expect(secretsTried).To(Equal([]string{"stale-secret"}))
+	})
+})
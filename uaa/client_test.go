@@ -2,7 +2,10 @@ package uaa_test
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -140,6 +143,128 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("WithIdentityZone", func() {
+		It("sends the zone switching headers", func() {
+			var headers http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				headers = req.Header
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client = uaa.NewClient(server.URL, false).WithIdentityZone("the-zone-id", "tenant")
+			client.MakeRequest("GET", "/Users", nil)
+
+			Expect(headers.Get("X-Identity-Zone-Id")).To(Equal("the-zone-id"))
+			Expect(headers.Get("X-Identity-Zone-Subdomain")).To(Equal("tenant"))
+		})
+	})
+
+	Describe("WithHeader", func() {
+		It("sends the header without changing the original client", func() {
+			var headers []http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				headers = append(headers, req.Header)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client = uaa.NewClient(server.URL, false)
+			client.WithHeader("If-Match", "3").MakeRequest("GET", "/Users", nil)
+			client.MakeRequest("GET", "/Users", nil)
+
+			Expect(headers[0].Get("If-Match")).To(Equal("3"))
+			Expect(headers[1].Get("If-Match")).To(Equal(""))
+		})
+	})
+
+	Describe("MakeJSONRequest", func() {
+		var requestBody string
+		var headers http.Header
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				body, _ := ioutil.ReadAll(req.Body)
+				requestBody = string(body)
+				headers = req.Header
+				if req.URL.Path == "/missing" {
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"error":"not_found","error_description":"Nothing here"}`))
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"name":"response"}`))
+			}))
+			client = uaa.NewClient(server.URL, false)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("encodes the request and decodes the response as JSON", func() {
+			var response struct {
+				Name string `json:"name"`
+			}
+			err := client.MakeJSONRequest("POST", "/something", map[string]string{"name": "request"}, &response)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requestBody).To(MatchJSON(`{"name":"request"}`))
+			Expect(headers.Get("Content-Type")).To(Equal("application/json"))
+			Expect(headers.Get("Accept")).To(Equal("application/json"))
+			Expect(response.Name).To(Equal("response"))
+		})
+
+		It("returns error responses as a Failure", func() {
+			err := client.MakeJSONRequest("GET", "/missing", nil, nil)
+
+			Expect(uaa.IsNotFound(err)).To(BeTrue())
+			Expect(err.(uaa.Failure).ErrorDescription()).To(Equal("Nothing here"))
+		})
+	})
+
+	Describe("MakeRequestWithContext", func() {
+		It("does not make the request when the context is already canceled", func() {
+			requestCount := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requestCount += 1
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			client = uaa.NewClient(server.URL, false).WithAuthorizationToken("my-special-token")
+			_, _, err := client.MakeRequestWithContext(ctx, "GET", "/something", nil)
+
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(requestCount).To(Equal(0))
+		})
+
+		It("is used by MakeRequest when the client carries a context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			client = uaa.NewClient("http://uaa.example.com", false).WithContext(ctx)
+			_, _, err := client.MakeRequest("GET", "/something", nil)
+
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		})
+	})
+
+	Describe("Context", func() {
+		It("defaults to the background context", func() {
+			Expect(client.Context()).To(Equal(context.Background()))
+		})
+
+		It("returns the context given to WithContext", func() {
+			ctx := context.WithValue(context.Background(), "key", "value")
+			Expect(client.WithContext(ctx).Context()).To(Equal(ctx))
+		})
+	})
+
 	Describe("GetClient", func() {
 		It("initializes the shared http client", func() {
 			client = uaa.NewClient("", false)
@@ -151,4 +276,34 @@ var _ = Describe("Client", func() {
 			Expect(reflect.ValueOf(httpClient1).Pointer()).To(Equal(reflect.ValueOf(httpClient2).Pointer()))
 		})
 	})
+
+	Describe("WithHTTPClient", func() {
+		It("sends requests with the given http client instead of the shared one", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			var sent []string
+			httpClient := &http.Client{
+				Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					sent = append(sent, req.URL.Path)
+					return http.DefaultTransport.RoundTrip(req)
+				}),
+			}
+			client = uaa.NewClient(server.URL, false).WithHTTPClient(httpClient)
+
+			code, _, err := client.MakeRequest("GET", "/token_key", nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(sent).To(Equal([]string{"/token_key"}))
+		})
+	})
 })
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,63 @@
+package uaa
+
+import (
+	"context"
+	"sync"
+)
+
+// Runs task for every index from 0 to count-1 with at most concurrency tasks
+// running at once. Tasks are given a UAA whose context is canceled once a
+// task fails, and the tasks that have not started by then are skipped. The
+// first failure is returned.
+func runConcurrently(u UAA, count, concurrency int, task func(u UAA, i int) error) error {
+	ctx, cancel := context.WithCancel(u.Context())
+	defer cancel()
+	u = u.WithContext(ctx)
+
+	var mutex sync.Mutex
+	var firstErr error
+	fail := func(err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > count {
+		concurrency = count
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				err := ctx.Err()
+				if err != nil {
+					fail(err)
+					continue
+				}
+
+				err = task(u, i)
+				if err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return firstErr
+}
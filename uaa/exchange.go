@@ -3,14 +3,16 @@ package uaa
 import (
 	"encoding/json"
 	"net/url"
-	"strings"
 )
 
 type ExchangeInterface interface {
 	Exchange(string) (Token, error)
 }
 
-func Exchange(u UAA, authCode string) (Token, error) {
+func Exchange(u UAA, authCode string) (_ Token, err error) {
+	u, span := u.withOperation("Exchange")
+	defer span.end(&err)
+
 	token := NewToken()
 
 	params := url.Values{
@@ -25,9 +27,7 @@ func Exchange(u UAA, authCode string) (Token, error) {
 		return token, err
 	}
 
-	host := uri.Scheme + "://" + uri.Host
-	client := NewClient(host, u.VerifySSL).WithBasicAuthCredentials(u.ClientID, u.ClientSecret)
-	code, body, err := client.MakeRequest("POST", uri.RequestURI(), strings.NewReader(params.Encode()))
+	code, body, err := u.makeClientAuthenticatedRequest(uri, params, false)
 	if err != nil {
 		return token, err
 	}
@@ -0,0 +1,143 @@
+package uaa
+
+import "net/url"
+
+type ExternalGroupMappingsInterface interface {
+	ExternalGroupMappings(ExternalGroupMappingsQuery) (ExternalGroupMappingsPage, error)
+	AllExternalGroupMappings(ExternalGroupMappingsQuery) ([]ExternalGroupMapping, error)
+	CreateExternalGroupMapping(ExternalGroupMapping) (ExternalGroupMapping, error)
+	DeleteExternalGroupMapping(ExternalGroupMapping) error
+}
+
+// Origin UAA assumes for mappings that do not name one
+const DefaultExternalGroupOrigin = "ldap"
+
+// Grants the members of a group of an external identity provider, like an
+// LDAP group DN or a SAML group name, the scope of a UAA group. The UAA group
+// is named by GroupID, or by DisplayName when GroupID is empty.
+type ExternalGroupMapping struct {
+	GroupID       string `json:"groupId,omitempty"`
+	DisplayName   string `json:"displayName,omitempty"`
+	ExternalGroup string `json:"externalGroup"`
+	Origin        string `json:"origin,omitempty"`
+}
+
+// Returns the mapping of the external group of the origin to the group
+func NewExternalGroupMapping(group Group, origin, externalGroup string) ExternalGroupMapping {
+	return ExternalGroupMapping{
+		GroupID:       group.ID,
+		DisplayName:   group.DisplayName,
+		ExternalGroup: externalGroup,
+		Origin:        origin,
+	}
+}
+
+// Query for a page of mappings. Origin and ExternalGroup only match the
+// mappings with that origin or external group, and are combined with Filter.
+// StartIndex is 1-based, and zero values are left to UAA.
+type ExternalGroupMappingsQuery struct {
+	Origin        string
+	ExternalGroup string
+	Filter        Filter
+	StartIndex    int
+	Count         int
+}
+
+func (query ExternalGroupMappingsQuery) filter() Filter {
+	var filters []Filter
+	if query.Origin != "" {
+		filters = append(filters, Eq("origin", query.Origin))
+	}
+	if query.ExternalGroup != "" {
+		filters = append(filters, Eq("externalGroup", query.ExternalGroup))
+	}
+	return And(append(filters, query.Filter)...)
+}
+
+func (query ExternalGroupMappingsQuery) values() url.Values {
+	return listQuery{
+		Filter:     query.filter(),
+		StartIndex: query.StartIndex,
+		Count:      query.Count,
+	}.values()
+}
+
+type ExternalGroupMappingsPage struct {
+	Mappings     []ExternalGroupMapping
+	StartIndex   int
+	ItemsPerPage int
+	TotalResults int
+}
+
+// Returns a single page of the mappings matching the query, use StartIndex
+// to request the next ones
+func ExternalGroupMappings(u UAA, query ExternalGroupMappingsQuery) (_ ExternalGroupMappingsPage, err error) {
+	u, span := u.withOperation("ExternalGroupMappings")
+	defer span.end(&err)
+
+	mappings := []ExternalGroupMapping{}
+	response, err := u.listRequest("/Groups/External", query.values(), &mappings)
+	if err != nil {
+		return ExternalGroupMappingsPage{Mappings: []ExternalGroupMapping{}}, err
+	}
+
+	return ExternalGroupMappingsPage{
+		Mappings:     mappings,
+		StartIndex:   response.StartIndex,
+		ItemsPerPage: response.ItemsPerPage,
+		TotalResults: response.TotalResults,
+	}, nil
+}
+
+// Pages through every mapping matching the query, starting at
+// query.StartIndex. The mappings fetched before a failure are returned along
+// with it.
+func AllExternalGroupMappings(u UAA, query ExternalGroupMappingsQuery) (_ []ExternalGroupMapping, err error) {
+	u, span := u.withOperation("AllExternalGroupMappings")
+	defer span.end(&err)
+
+	mappings := []ExternalGroupMapping{}
+	err = pageThrough(u, query.StartIndex, func(startIndex int) (int, int, error) {
+		page := []ExternalGroupMapping{}
+		query.StartIndex = startIndex
+		response, err := u.listRequest("/Groups/External", query.values(), &page)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		mappings = append(mappings, page...)
+		return len(page), response.TotalResults, nil
+	})
+	return mappings, err
+}
+
+// Maps the external group to the UAA group. Mapping it twice is reported as
+// a ConflictError.
+func CreateExternalGroupMapping(u UAA, mapping ExternalGroupMapping) (_ ExternalGroupMapping, err error) {
+	u, span := u.withOperation("CreateExternalGroupMapping")
+	defer span.end(&err)
+
+	var created ExternalGroupMapping
+	err = u.adminRequest("POST", "/Groups/External", nil, mapping, &created)
+	return created, err
+}
+
+// Removes the mapping of the external group to the UAA group, named by its
+// ID or else by its display name
+func DeleteExternalGroupMapping(u UAA, mapping ExternalGroupMapping) (err error) {
+	u, span := u.withOperation("DeleteExternalGroupMapping")
+	defer span.end(&err)
+
+	origin := mapping.Origin
+	if origin == "" {
+		origin = DefaultExternalGroupOrigin
+	}
+
+	path := "/Groups/External/groupId/" + url.PathEscape(mapping.GroupID)
+	if mapping.GroupID == "" {
+		path = "/Groups/External/displayName/" + url.PathEscape(mapping.DisplayName)
+	}
+	path += "/externalGroup/" + url.PathEscape(mapping.ExternalGroup) + "/origin/" + url.PathEscape(origin)
+
+	return u.adminRequest("DELETE", path, nil, nil, nil)
+}
@@ -0,0 +1,179 @@
+package uaa_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExternalGroupMappings", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var requests []*http.Request
+	var requestBodies []string
+
+	BeforeEach(func() {
+		requests = []*http.Request{}
+		requestBodies = []string{}
+		fakeUAAServer = httptest.NewServer(recordRequests(&requests, &requestBodies, func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "Bearer my-special-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			switch {
+			case req.URL.Path == "/Groups/External" && req.Method == "GET":
+				startIndex, _ := strconv.Atoi(req.URL.Query().Get("startIndex"))
+				if startIndex == 0 {
+					startIndex = 1
+				}
+				resources := []map[string]string{}
+				for i := startIndex; i < startIndex+2 && i <= 3; i++ {
+					resources = append(resources, map[string]string{
+						"groupId":       "group-" + strconv.Itoa(i),
+						"displayName":   "scope." + strconv.Itoa(i),
+						"externalGroup": "cn=group-" + strconv.Itoa(i) + ",ou=groups,dc=example,dc=com",
+						"origin":        "ldap",
+					})
+				}
+				response, _ := json.Marshal(map[string]interface{}{
+					"resources":    resources,
+					"startIndex":   startIndex,
+					"itemsPerPage": len(resources),
+					"totalResults": 3,
+				})
+				w.WriteHeader(http.StatusOK)
+				w.Write(response)
+			case req.URL.Path == "/Groups/External" && req.Method == "POST":
+				var mapping map[string]string
+				json.NewDecoder(req.Body).Decode(&mapping)
+				if mapping["externalGroup"] == "mapped" {
+					w.WriteHeader(http.StatusConflict)
+					w.Write([]byte(`{"error":"scim_resource_already_exists","error_description":"The mapping already exists"}`))
+					return
+				}
+				mapping["groupId"] = "group-1"
+				mapping["displayName"] = "scope.1"
+				response, _ := json.Marshal(mapping)
+				w.WriteHeader(http.StatusCreated)
+				w.Write(response)
+			case req.Method == "DELETE":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"groupId": "group-1", "displayName": "scope.1", "externalGroup": "admins", "origin": "saml"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	It("filters the mappings by origin and external group", func() {
+		page, err := uaa.ExternalGroupMappings(auth, uaa.ExternalGroupMappingsQuery{
+			Origin:        "ldap",
+			ExternalGroup: "cn=group-3,ou=groups,dc=example,dc=com",
+			Filter:        uaa.Sw("displayName", "scope."),
+			StartIndex:    3,
+			Count:         2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests[0].URL.Query()).To(Equal(url.Values{
+			"filter":     {`origin eq "ldap" and externalGroup eq "cn=group-3,ou=groups,dc=example,dc=com" and displayName sw "scope."`},
+			"startIndex": {"3"},
+			"count":      {"2"},
+		}))
+		Expect(page).To(Equal(uaa.ExternalGroupMappingsPage{
+			Mappings: []uaa.ExternalGroupMapping{{
+				GroupID:       "group-3",
+				DisplayName:   "scope.3",
+				ExternalGroup: "cn=group-3,ou=groups,dc=example,dc=com",
+				Origin:        "ldap",
+			}},
+			StartIndex:   3,
+			ItemsPerPage: 1,
+			TotalResults: 3,
+		}))
+	})
+
+	It("leaves unset options to UAA", func() {
+		_, err := uaa.ExternalGroupMappings(auth, uaa.ExternalGroupMappingsQuery{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests[0].URL.RawQuery).To(BeEmpty())
+	})
+
+	It("pages through every mapping", func() {
+		mappings, err := uaa.AllExternalGroupMappings(auth, uaa.ExternalGroupMappingsQuery{Origin: "ldap"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(mappings).To(HaveLen(3))
+		Expect(mappings[2].GroupID).To(Equal("group-3"))
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[1].URL.Query().Get("startIndex")).To(Equal("3"))
+		Expect(requests[1].URL.Query().Get("filter")).To(Equal(`origin eq "ldap"`))
+	})
+
+	It("reports every page under the AllExternalGroupMappings operation", func() {
+		metrics := &fakeMetrics{}
+		auth.Metrics = metrics
+
+		_, err := uaa.AllExternalGroupMappings(auth, uaa.ExternalGroupMappingsQuery{Origin: "ldap"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(metrics.observations).To(HaveLen(2))
+		Expect(metrics.observations[0].operation).To(Equal("AllExternalGroupMappings"))
+		Expect(metrics.observations[1].operation).To(Equal("AllExternalGroupMappings"))
+	})
+
+	Describe("CreateExternalGroupMapping", func() {
+		It("maps the external group to the group", func() {
+			group := uaa.Group{ID: "group-1", DisplayName: "scope.1"}
+
+			mapping, err := uaa.CreateExternalGroupMapping(auth, uaa.NewExternalGroupMapping(group, "saml", "admins"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Method).To(Equal("POST"))
+			Expect(requestBodies[0]).To(MatchJSON(`{"groupId": "group-1", "displayName": "scope.1", "externalGroup": "admins", "origin": "saml"}`))
+			Expect(mapping).To(Equal(uaa.ExternalGroupMapping{GroupID: "group-1", DisplayName: "scope.1", ExternalGroup: "admins", Origin: "saml"}))
+		})
+
+		It("returns a conflict when the mapping exists", func() {
+			_, err := uaa.CreateExternalGroupMapping(auth, uaa.ExternalGroupMapping{DisplayName: "scope.1", ExternalGroup: "mapped"})
+
+			Expect(uaa.IsConflict(err)).To(BeTrue())
+		})
+	})
+
+	Describe("DeleteExternalGroupMapping", func() {
+		It("deletes the mapping of the group ID", func() {
+			err := uaa.DeleteExternalGroupMapping(auth, uaa.ExternalGroupMapping{
+				GroupID:       "group-1",
+				DisplayName:   "scope.1",
+				ExternalGroup: "cn=admins/ops,ou=groups",
+				Origin:        "ldap",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Method).To(Equal("DELETE"))
+			Expect(requests[0].URL.EscapedPath()).To(Equal("/Groups/External/groupId/group-1/externalGroup/cn=admins%2Fops%2Cou=groups/origin/ldap"))
+		})
+
+		It("deletes the mapping of the display name when there is no group ID", func() {
+			err := uaa.DeleteExternalGroupMapping(auth, uaa.ExternalGroupMapping{DisplayName: "scope.1", ExternalGroup: "admins"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].URL.EscapedPath()).To(Equal("/Groups/External/displayName/scope.1/externalGroup/admins/origin/ldap"))
+		})
+	})
+})
@@ -0,0 +1,138 @@
+package uaa
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SCIM filter expression, like `userName eq "admin" and active eq true`.
+// Values are quoted and escaped, attribute names are used as they are, so
+// they must not come from user input. The zero Filter matches everything
+// and is left out of And and Or.
+type Filter struct {
+	expression string
+	operator   string
+}
+
+// Matches resources whose attribute equals the value
+func Eq(attribute string, value interface{}) Filter {
+	return comparison(attribute, "eq", value)
+}
+
+// Matches resources whose attribute contains the value
+func Co(attribute string, value interface{}) Filter {
+	return comparison(attribute, "co", value)
+}
+
+// Matches resources whose attribute starts with the value
+func Sw(attribute string, value interface{}) Filter {
+	return comparison(attribute, "sw", value)
+}
+
+// Matches resources that have a value for the attribute
+func Pr(attribute string) Filter {
+	return Filter{expression: attribute + " pr"}
+}
+
+// Matches resources whose attribute is greater than the value
+func Gt(attribute string, value interface{}) Filter {
+	return comparison(attribute, "gt", value)
+}
+
+// Matches resources whose attribute is less than the value
+func Lt(attribute string, value interface{}) Filter {
+	return comparison(attribute, "lt", value)
+}
+
+// Matches resources matching every filter
+func And(filters ...Filter) Filter {
+	return join("and", filters)
+}
+
+// Matches resources matching any of the filters
+func Or(filters ...Filter) Filter {
+	return join("or", filters)
+}
+
+// Matches resources not matching the filter
+func Not(filter Filter) Filter {
+	if filter.IsZero() {
+		return filter
+	}
+	return Filter{expression: "not " + Parens(filter).expression}
+}
+
+// Wraps the filter in parentheses. And and Or already group their operands
+// where precedence requires it.
+func Parens(filter Filter) Filter {
+	if filter.IsZero() {
+		return filter
+	}
+	return Filter{expression: "(" + filter.expression + ")"}
+}
+
+// Wraps filter expressions built by hand, like "userName eq \"jane\""
+func filtersFromExpressions(expressions []string) []Filter {
+	filters := make([]Filter, len(expressions))
+	for i, expression := range expressions {
+		filters[i] = Filter{expression: expression}
+	}
+	return filters
+}
+
+func (filter Filter) IsZero() bool {
+	return filter.expression == ""
+}
+
+func (filter Filter) String() string {
+	return filter.expression
+}
+
+func comparison(attribute, operator string, value interface{}) Filter {
+	return Filter{expression: fmt.Sprintf("%s %s %s", attribute, operator, filterValue(value))}
+}
+
+func join(operator string, filters []Filter) Filter {
+	var operands []Filter
+	for _, filter := range filters {
+		if filter.IsZero() {
+			continue
+		}
+		// "and" binds tighter than "or"
+		if operator == "and" && filter.operator == "or" {
+			filter = Parens(filter)
+		}
+		operands = append(operands, filter)
+	}
+
+	switch len(operands) {
+	case 0:
+		return Filter{}
+	case 1:
+		return operands[0]
+	}
+
+	expressions := make([]string, len(operands))
+	for i, operand := range operands {
+		expressions[i] = operand.expression
+	}
+	return Filter{expression: strings.Join(expressions, " "+operator+" "), operator: operator}
+}
+
+var filterValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// Quotes strings and times, and writes booleans, numbers and nil as JSON
+// literals
+func filterValue(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case time.Time:
+		return `"` + value.UTC().Format("2006-01-02T15:04:05.000Z") + `"`
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(value)
+	default:
+		return `"` + filterValueEscaper.Replace(fmt.Sprint(value)) + `"`
+	}
+}
@@ -0,0 +1,90 @@
+package uaa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Filter", func() {
+	It("builds comparisons", func() {
+		Expect(uaa.Eq("userName", "admin").String()).To(Equal(`userName eq "admin"`))
+		Expect(uaa.Co("emails.value", "@corp.com").String()).To(Equal(`emails.value co "@corp.com"`))
+		Expect(uaa.Sw("userName", "adm").String()).To(Equal(`userName sw "adm"`))
+		Expect(uaa.Pr("externalId").String()).To(Equal(`externalId pr`))
+		Expect(uaa.Gt("meta.version", 3).String()).To(Equal(`meta.version gt 3`))
+		Expect(uaa.Lt("meta.lastModified", time.Date(2014, 6, 25, 23, 10, 3, 845000000, time.UTC)).String()).To(Equal(`meta.lastModified lt "2014-06-25T23:10:03.845Z"`))
+		Expect(uaa.Eq("active", true).String()).To(Equal(`active eq true`))
+	})
+
+	It("escapes quotes and backslashes in values", func() {
+		Expect(uaa.Eq("displayName", `scope" or displayName pr or displayName eq "`).String()).To(Equal(`displayName eq "scope\" or displayName pr or displayName eq \""`))
+		Expect(uaa.Eq("userName", `domain\user`).String()).To(Equal(`userName eq "domain\\user"`))
+	})
+
+	It("combines filters", func() {
+		filter := uaa.And(uaa.Eq("origin", "uaa"), uaa.Not(uaa.Eq("active", false)))
+		Expect(filter.String()).To(Equal(`origin eq "uaa" and not (active eq false)`))
+
+		filter = uaa.Or(uaa.Eq("Id", "1"), uaa.Eq("Id", "2"), uaa.Eq("Id", "3"))
+		Expect(filter.String()).To(Equal(`Id eq "1" or Id eq "2" or Id eq "3"`))
+
+		filter = uaa.Parens(uaa.Pr("externalId"))
+		Expect(filter.String()).To(Equal(`(externalId pr)`))
+	})
+
+	It("groups or filters inside and filters", func() {
+		filter := uaa.And(uaa.Or(uaa.Eq("origin", "uaa"), uaa.Eq("origin", "ldap")), uaa.Eq("active", true))
+		Expect(filter.String()).To(Equal(`(origin eq "uaa" or origin eq "ldap") and active eq true`))
+
+		filter = uaa.And(uaa.And(uaa.Or(uaa.Eq("origin", "uaa"), uaa.Eq("origin", "ldap"))), uaa.Eq("active", true))
+		Expect(filter.String()).To(Equal(`(origin eq "uaa" or origin eq "ldap") and active eq true`))
+
+		filter = uaa.Or(uaa.And(uaa.Eq("origin", "uaa"), uaa.Eq("active", true)), uaa.Eq("origin", "ldap"))
+		Expect(filter.String()).To(Equal(`origin eq "uaa" and active eq true or origin eq "ldap"`))
+	})
+
+	It("leaves zero filters out", func() {
+		Expect(uaa.And().IsZero()).To(BeTrue())
+		Expect(uaa.And(uaa.Filter{}, uaa.Eq("active", true)).String()).To(Equal(`active eq true`))
+		Expect(uaa.Or(uaa.Filter{}, uaa.Filter{}).IsZero()).To(BeTrue())
+		Expect(uaa.Not(uaa.Filter{}).IsZero()).To(BeTrue())
+	})
+
+	Describe("queries built on it", func() {
+		var fakeUAAServer *httptest.Server
+		var filters []string
+		var auth uaa.UAA
+
+		BeforeEach(func() {
+			filters = []string{}
+			fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				filters = append(filters, req.URL.Query().Get("filter"))
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"resources": [{"members": []}], "totalResults": 0}`))
+			}))
+			auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+		})
+
+		AfterEach(func() {
+			fakeUAAServer.Close()
+		})
+
+		It("escapes the values they are given", func() {
+			uaa.UsersByIDs(auth, `1" or Id pr or Id eq "`)
+			uaa.UsersEmailsByIDs(auth, `1" or Id pr or Id eq "`)
+			uaa.UsersGUIDsByScope(auth, `scope" or displayName pr or displayName eq "`)
+
+			Expect(filters).To(Equal([]string{
+				`Id eq "1\" or Id pr or Id eq \""`,
+				`Id eq "1\" or Id pr or Id eq \""`,
+				`displayName eq "scope\" or displayName pr or displayName eq \""`,
+			}))
+		})
+	})
+})
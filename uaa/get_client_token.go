@@ -3,7 +3,6 @@ package uaa
 import (
 	"encoding/json"
 	"net/url"
-	"strings"
 )
 
 type GetClientTokenInterface interface {
@@ -11,7 +10,10 @@ type GetClientTokenInterface interface {
 }
 
 // Retrieves ClientToken from UAA server
-func GetClientToken(u UAA) (Token, error) {
+func GetClientToken(u UAA) (_ Token, err error) {
+	u, span := u.withOperation("GetClientToken")
+	defer span.end(&err)
+
 	token := NewToken()
 	params := url.Values{
 		"grant_type":   {"client_credentials"},
@@ -23,9 +25,8 @@ func GetClientToken(u UAA) (Token, error) {
 		return token, err
 	}
 
-	host := uri.Scheme + "://" + uri.Host
-	client := NewClient(host, u.VerifySSL).WithBasicAuthCredentials(u.ClientID, u.ClientSecret)
-	code, body, err := client.MakeRequest("POST", uri.RequestURI(), strings.NewReader(params.Encode()))
+	// client_credentials grants have no side effects, so they are safe to retry
+	code, body, err := u.makeClientAuthenticatedRequest(uri, params, true)
 	if err != nil {
 		return token, err
 	}
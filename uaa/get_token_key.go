@@ -9,7 +9,10 @@ type GetTokenKeyInterface interface {
 	GetTokenKey() (string, error)
 }
 
-func GetTokenKey(u UAA) (string, error) {
+func GetTokenKey(u UAA) (_ string, err error) {
+	u, span := u.withOperation("GetTokenKey")
+	defer span.end(&err)
+
 	tokenURL := u.uaaURL + "/token_key"
 	uri, err := url.Parse(tokenURL)
 	if err != nil {
@@ -21,9 +24,7 @@ func GetTokenKey(u UAA) (string, error) {
 		return "", err
 	}
 
-	host := uri.Scheme + "://" + uri.Host
-
-	client := NewClient(host, u.VerifySSL).WithAuthorizationToken(token.Access)
+	client := u.newClient(uri).WithAuthorizationToken(token.Access)
 	code, body, err := client.MakeRequest("GET", uri.RequestURI(), nil)
 	if err != nil {
 		return "", err
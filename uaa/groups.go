@@ -0,0 +1,238 @@
+package uaa
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+type GroupsInterface interface {
+	CreateGroup(Group) (Group, error)
+	GroupByID(string) (Group, error)
+	Groups(GroupsQuery) (GroupsPage, error)
+	AllGroups(Filter) ([]Group, error)
+	UpdateGroup(Group) (Group, error)
+	DeleteGroup(string) error
+	GroupMembers(string) ([]GroupMember, error)
+	AddGroupMember(string, GroupMember) (GroupMember, error)
+	RemoveGroupMember(string, string) error
+}
+
+// Types of group members
+const (
+	GroupMemberUser  = "USER"
+	GroupMemberGroup = "GROUP"
+)
+
+// SCIM group. Its display name is the scope it grants to its members.
+// UpdateGroup replaces the members with Members, use the member operations
+// to change them one at a time.
+type Group struct {
+	ID          string
+	DisplayName string
+	Description string
+	Members     []GroupMember
+	ZoneID      string
+	Meta        Meta
+}
+
+// User or nested group that belongs to a group. Value is the ID of the
+// member, Type is GroupMemberUser or GroupMemberGroup, and Origin is the
+// identity origin of user members.
+type GroupMember struct {
+	Value  string `json:"value"`
+	Type   string `json:"type"`
+	Origin string `json:"origin,omitempty"`
+}
+
+type groupJSON struct {
+	Schemas     []string      `json:"schemas,omitempty"`
+	ID          string        `json:"id,omitempty"`
+	Meta        *Meta         `json:"meta,omitempty"`
+	DisplayName string        `json:"displayName"`
+	Description string        `json:"description,omitempty"`
+	Members     []GroupMember `json:"members"`
+	ZoneID      string        `json:"zoneId,omitempty"`
+}
+
+// Encodes the group as a SCIM resource
+func (group Group) MarshalJSON() ([]byte, error) {
+	encoded := groupJSON{
+		Schemas:     []string{"urn:scim:schemas:core:1.0"},
+		ID:          group.ID,
+		DisplayName: group.DisplayName,
+		Description: group.Description,
+		Members:     group.Members,
+		ZoneID:      group.ZoneID,
+	}
+
+	if encoded.Members == nil {
+		encoded.Members = []GroupMember{}
+	}
+
+	if group.Meta != (Meta{}) {
+		meta := group.Meta
+		encoded.Meta = &meta
+	}
+
+	return json.Marshal(encoded)
+}
+
+func (group *Group) UnmarshalJSON(data []byte) error {
+	var decoded groupJSON
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+
+	*group = Group{
+		ID:          decoded.ID,
+		DisplayName: decoded.DisplayName,
+		Description: decoded.Description,
+		Members:     decoded.Members,
+		ZoneID:      decoded.ZoneID,
+	}
+
+	if decoded.Meta != nil {
+		group.Meta = *decoded.Meta
+	}
+
+	return nil
+}
+
+// Query for a page of groups. Attributes limits the attributes UAA returns,
+// like []string{"id", "displayName"}. StartIndex is 1-based, and zero values
+// are left to UAA.
+type GroupsQuery struct {
+	Filter     Filter
+	SortBy     string
+	SortOrder  string
+	Attributes []string
+	StartIndex int
+	Count      int
+}
+
+func (query GroupsQuery) values() url.Values {
+	return listQuery(query).values()
+}
+
+type GroupsPage struct {
+	Groups       []Group
+	StartIndex   int
+	ItemsPerPage int
+	TotalResults int
+}
+
+// Creates the group. A display name that is already taken is reported as a
+// ConflictError.
+func CreateGroup(u UAA, group Group) (_ Group, err error) {
+	u, span := u.withOperation("CreateGroup")
+	defer span.end(&err)
+
+	var created Group
+	err = u.adminRequest("POST", "/Groups", nil, group, &created)
+	return created, err
+}
+
+func GroupByID(u UAA, id string) (_ Group, err error) {
+	u, span := u.withOperation("GroupByID")
+	defer span.end(&err)
+
+	var group Group
+	err = u.adminRequest("GET", "/Groups/"+url.PathEscape(id), nil, nil, &group)
+	return group, err
+}
+
+// Returns a single page of the groups matching the query, use StartIndex to
+// request the next ones
+func Groups(u UAA, query GroupsQuery) (_ GroupsPage, err error) {
+	u, span := u.withOperation("Groups")
+	defer span.end(&err)
+
+	groups := []Group{}
+	response, err := u.listRequest("/Groups", query.values(), &groups)
+	if err != nil {
+		return GroupsPage{Groups: []Group{}}, err
+	}
+
+	return GroupsPage{
+		Groups:       groups,
+		StartIndex:   response.StartIndex,
+		ItemsPerPage: response.ItemsPerPage,
+		TotalResults: response.TotalResults,
+	}, nil
+}
+
+// Pages through every group matching the filter, or every group when the
+// filter is zero. The groups fetched before a failure are returned along
+// with it.
+func AllGroups(u UAA, filter Filter) (_ []Group, err error) {
+	u, span := u.withOperation("AllGroups")
+	defer span.end(&err)
+
+	groups := []Group{}
+	err = pageThrough(u, 1, func(startIndex int) (int, int, error) {
+		page := []Group{}
+		query := GroupsQuery{Filter: filter, StartIndex: startIndex}
+		response, err := u.listRequest("/Groups", query.values(), &page)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		groups = append(groups, page...)
+		return len(page), response.TotalResults, nil
+	})
+	return groups, err
+}
+
+// Replaces the group. The update only applies when the group is still at
+// group.Meta.Version, otherwise a ConflictError is returned. Groups without
+// Meta, which were not read from UAA, are rejected with MissingVersionError
+// unless Meta.Version is set to AnyVersion.
+func UpdateGroup(u UAA, group Group) (_ Group, err error) {
+	u, span := u.withOperation("UpdateGroup")
+	defer span.end(&err)
+
+	version, err := metaVersion(&group.Meta)
+	if err != nil {
+		return Group{}, err
+	}
+
+	var updated Group
+	err = u.adminRequest("PUT", "/Groups/"+url.PathEscape(group.ID), ifMatch(version), group, &updated)
+	return updated, err
+}
+
+func DeleteGroup(u UAA, id string) (err error) {
+	u, span := u.withOperation("DeleteGroup")
+	defer span.end(&err)
+
+	return u.adminRequest("DELETE", "/Groups/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// Returns the direct members of the group
+func GroupMembers(u UAA, groupID string) (_ []GroupMember, err error) {
+	u, span := u.withOperation("GroupMembers")
+	defer span.end(&err)
+
+	members := []GroupMember{}
+	err = u.adminRequest("GET", "/Groups/"+url.PathEscape(groupID)+"/members", nil, nil, &members)
+	return members, err
+}
+
+// Adds a user or a nested group to the group. Adding a member twice is
+// reported as a ConflictError.
+func AddGroupMember(u UAA, groupID string, member GroupMember) (_ GroupMember, err error) {
+	u, span := u.withOperation("AddGroupMember")
+	defer span.end(&err)
+
+	var added GroupMember
+	err = u.adminRequest("POST", "/Groups/"+url.PathEscape(groupID)+"/members", nil, member, &added)
+	return added, err
+}
+
+func RemoveGroupMember(u UAA, groupID, memberID string) (err error) {
+	u, span := u.withOperation("RemoveGroupMember")
+	defer span.end(&err)
+
+	return u.adminRequest("DELETE", "/Groups/"+url.PathEscape(groupID)+"/members/"+url.PathEscape(memberID), nil, nil, nil)
+}
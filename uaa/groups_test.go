@@ -0,0 +1,331 @@
+package uaa_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const createdGroupJSON = `{
+  "id": "e7f74565-4c7e-44ba-b068-b16072cbf08f",
+  "meta": {
+    "version": 0,
+    "created": "2014-05-22T22:36:36.941Z",
+    "lastModified": "2014-05-22T22:36:36.941Z"
+  },
+  "displayName": "notifications.write",
+  "description": "Send notifications",
+  "members": [
+    {"value": "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", "type": "USER", "origin": "uaa"},
+    {"value": "6b6ff5a2-3f0f-4c02-a3b4-4fa3a8a5b6c1", "type": "GROUP", "origin": "uaa"}
+  ],
+  "zoneId": "uaa",
+  "schemas": [
+    "urn:scim:schemas:core:1.0"
+  ]
+}`
+
+var _ = Describe("Groups", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var requests []*http.Request
+	var requestBodies []string
+
+	BeforeEach(func() {
+		requests = []*http.Request{}
+		requestBodies = []string{}
+		fakeUAAServer = httptest.NewServer(recordRequests(&requests, &requestBodies, func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "Bearer my-special-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			switch {
+			case req.URL.Path == "/Groups" && req.Method == "POST":
+				var group map[string]interface{}
+				json.NewDecoder(req.Body).Decode(&group)
+				if group["displayName"] == "taken" {
+					w.WriteHeader(http.StatusConflict)
+					w.Write([]byte(`{"error":"scim_resource_already_exists","error_description":"A group with displayName: taken already exists."}`))
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(createdGroupJSON))
+			case req.URL.Path == "/Groups" && req.Method == "GET":
+				startIndex, _ := strconv.Atoi(req.URL.Query().Get("startIndex"))
+				resources := []map[string]string{}
+				for i := startIndex; i < startIndex+2 && i <= 3; i++ {
+					resources = append(resources, map[string]string{"id": "group-" + strconv.Itoa(i), "displayName": "scope." + strconv.Itoa(i)})
+				}
+				response, _ := json.Marshal(map[string]interface{}{
+					"resources":    resources,
+					"startIndex":   startIndex,
+					"itemsPerPage": len(resources),
+					"totalResults": 3,
+				})
+				w.WriteHeader(http.StatusOK)
+				w.Write(response)
+			case req.URL.Path == "/Groups/e7f74565-4c7e-44ba-b068-b16072cbf08f":
+				ifMatch := req.Header.Get("If-Match")
+				if req.Method == "PUT" && ifMatch != "*" && ifMatch != "0" {
+					w.WriteHeader(http.StatusConflict)
+					w.Write([]byte(`{"error":"scim_resource_conflict","error_description":"Version mismatch"}`))
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(createdGroupJSON))
+			case req.URL.Path == "/Groups/e7f74565-4c7e-44ba-b068-b16072cbf08f/members" && req.Method == "GET":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[
+                    {"value": "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", "type": "USER", "origin": "uaa"},
+                    {"value": "6b6ff5a2-3f0f-4c02-a3b4-4fa3a8a5b6c1", "type": "GROUP", "origin": "uaa"}
+                ]`))
+			case req.URL.Path == "/Groups/e7f74565-4c7e-44ba-b068-b16072cbf08f/members" && req.Method == "POST":
+				w.WriteHeader(http.StatusCreated)
+				io.Copy(w, req.Body)
+			case req.URL.Path == "/Groups/e7f74565-4c7e-44ba-b068-b16072cbf08f/members/87dfc5b4-daf9-49fd-9aa8-bb1e21d28929" && req.Method == "DELETE":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"value": "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", "type": "USER", "origin": "uaa"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":"scim_resource_not_found","error_description":"Group does not exist"}`))
+			}
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	expectedGroup := uaa.Group{
+		ID:          "e7f74565-4c7e-44ba-b068-b16072cbf08f",
+		DisplayName: "notifications.write",
+		Description: "Send notifications",
+		Members: []uaa.GroupMember{
+			{Value: "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", Type: uaa.GroupMemberUser, Origin: "uaa"},
+			{Value: "6b6ff5a2-3f0f-4c02-a3b4-4fa3a8a5b6c1", Type: uaa.GroupMemberGroup, Origin: "uaa"},
+		},
+		ZoneID: "uaa",
+		Meta: uaa.Meta{
+			Created:      time.Date(2014, 5, 22, 22, 36, 36, 941000000, time.UTC),
+			LastModified: time.Date(2014, 5, 22, 22, 36, 36, 941000000, time.UTC),
+		},
+	}
+
+	Describe("CreateGroup", func() {
+		It("creates the group and returns it as stored by UAA", func() {
+			group, err := uaa.CreateGroup(auth, uaa.Group{
+				DisplayName: "notifications.write",
+				Description: "Send notifications",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Method).To(Equal("POST"))
+			Expect(requests[0].URL.Path).To(Equal("/Groups"))
+			Expect(requestBodies[0]).To(MatchJSON(`{
+                "schemas": ["urn:scim:schemas:core:1.0"],
+                "displayName": "notifications.write",
+                "description": "Send notifications",
+                "members": []
+            }`))
+
+			Expect(group).To(Equal(expectedGroup))
+		})
+
+		It("returns a conflict when the display name is taken", func() {
+			_, err := uaa.CreateGroup(auth, uaa.Group{DisplayName: "taken"})
+
+			Expect(uaa.IsConflict(err)).To(BeTrue())
+		})
+	})
+
+	Describe("GroupByID", func() {
+		It("returns the group", func() {
+			group, err := uaa.GroupByID(auth, "e7f74565-4c7e-44ba-b068-b16072cbf08f")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Method).To(Equal("GET"))
+			Expect(group).To(Equal(expectedGroup))
+		})
+
+		It("returns an error for unknown groups", func() {
+			_, err := uaa.GroupByID(auth, "unknown")
+
+			Expect(uaa.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Describe("Groups", func() {
+		It("sends the query to UAA and returns the page", func() {
+			page, err := uaa.Groups(auth, uaa.GroupsQuery{
+				Filter:     uaa.Sw("displayName", "scope."),
+				SortBy:     "displayName",
+				SortOrder:  "descending",
+				Attributes: []string{"id", "displayName"},
+				StartIndex: 3,
+				Count:      2,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].URL.Query()).To(Equal(url.Values{
+				"filter":     {`displayName sw "scope."`},
+				"sortBy":     {"displayName"},
+				"sortOrder":  {"descending"},
+				"attributes": {"id,displayName"},
+				"startIndex": {"3"},
+				"count":      {"2"},
+			}))
+			Expect(page).To(Equal(uaa.GroupsPage{
+				Groups:       []uaa.Group{{ID: "group-3", DisplayName: "scope.3"}},
+				StartIndex:   3,
+				ItemsPerPage: 1,
+				TotalResults: 3,
+			}))
+		})
+	})
+
+	Describe("AllGroups", func() {
+		It("pages through every group", func() {
+			groups, err := uaa.AllGroups(auth, uaa.Sw("displayName", "scope."))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(groups).To(Equal([]uaa.Group{
+				{ID: "group-1", DisplayName: "scope.1"},
+				{ID: "group-2", DisplayName: "scope.2"},
+				{ID: "group-3", DisplayName: "scope.3"},
+			}))
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[1].URL.Query().Get("startIndex")).To(Equal("3"))
+			Expect(requests[1].URL.Query().Get("filter")).To(Equal(`displayName sw "scope."`))
+		})
+
+		It("reports every page under the AllGroups operation", func() {
+			metrics := &fakeMetrics{}
+			auth.Metrics = metrics
+
+			_, err := uaa.AllGroups(auth, uaa.Filter{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(metrics.observations).To(HaveLen(2))
+			Expect(metrics.observations[0].operation).To(Equal("AllGroups"))
+			Expect(metrics.observations[1].operation).To(Equal("AllGroups"))
+		})
+	})
+
+	Describe("UpdateGroup", func() {
+		It("updates groups at the version they were read at", func() {
+			group, err := uaa.GroupByID(auth, "e7f74565-4c7e-44ba-b068-b16072cbf08f")
+			Expect(err).NotTo(HaveOccurred())
+
+			group.Description = "Send all the notifications"
+			_, err = uaa.UpdateGroup(auth, group)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[1].Method).To(Equal("PUT"))
+			Expect(requests[1].Header.Get("If-Match")).To(Equal("0"))
+			Expect(requestBodies[1]).To(ContainSubstring(`"description":"Send all the notifications"`))
+			Expect(requestBodies[1]).To(ContainSubstring(`"members":[{"value":"87dfc5b4-daf9-49fd-9aa8-bb1e21d28929","type":"USER","origin":"uaa"}`))
+		})
+
+		It("returns a conflict when the version does not match", func() {
+			group := expectedGroup
+			group.Meta.Version = 3
+			_, err := uaa.UpdateGroup(auth, group)
+
+			Expect(uaa.IsConflict(err)).To(BeTrue())
+		})
+
+		It("refuses to update groups that were not read from UAA", func() {
+			_, err := uaa.UpdateGroup(auth, uaa.Group{ID: "e7f74565-4c7e-44ba-b068-b16072cbf08f", DisplayName: "notifications.write"})
+
+			Expect(err).To(Equal(uaa.MissingVersionError))
+			Expect(requests).To(BeEmpty())
+		})
+
+		It("updates groups whatever their version with AnyVersion", func() {
+			_, err := uaa.UpdateGroup(auth, uaa.Group{
+				ID:          "e7f74565-4c7e-44ba-b068-b16072cbf08f",
+				DisplayName: "notifications.write",
+				Meta:        uaa.Meta{Version: uaa.AnyVersion},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Header.Get("If-Match")).To(Equal("*"))
+			Expect(requestBodies[0]).NotTo(ContainSubstring("meta"))
+		})
+	})
+
+	Describe("DeleteGroup", func() {
+		It("deletes the group", func() {
+			err := uaa.DeleteGroup(auth, "e7f74565-4c7e-44ba-b068-b16072cbf08f")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Method).To(Equal("DELETE"))
+		})
+
+		It("returns an error for unknown groups", func() {
+			err := uaa.DeleteGroup(auth, "unknown")
+
+			Expect(uaa.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Describe("GroupMembers", func() {
+		It("returns the members of the group", func() {
+			members, err := uaa.GroupMembers(auth, "e7f74565-4c7e-44ba-b068-b16072cbf08f")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(members).To(Equal(expectedGroup.Members))
+		})
+	})
+
+	Describe("AddGroupMember", func() {
+		It("adds the member to the group", func() {
+			member, err := uaa.AddGroupMember(auth, "e7f74565-4c7e-44ba-b068-b16072cbf08f", uaa.GroupMember{
+				Value: "6b6ff5a2-3f0f-4c02-a3b4-4fa3a8a5b6c1",
+				Type:  uaa.GroupMemberGroup,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Method).To(Equal("POST"))
+			Expect(requestBodies[0]).To(MatchJSON(`{"value": "6b6ff5a2-3f0f-4c02-a3b4-4fa3a8a5b6c1", "type": "GROUP"}`))
+			Expect(member).To(Equal(uaa.GroupMember{Value: "6b6ff5a2-3f0f-4c02-a3b4-4fa3a8a5b6c1", Type: uaa.GroupMemberGroup}))
+		})
+	})
+
+	Describe("RemoveGroupMember", func() {
+		It("removes the member from the group", func() {
+			err := uaa.RemoveGroupMember(auth, "e7f74565-4c7e-44ba-b068-b16072cbf08f", "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Method).To(Equal("DELETE"))
+		})
+
+		It("returns an error for members of unknown groups", func() {
+			err := uaa.RemoveGroupMember(auth, "unknown", "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929")
+
+			Expect(uaa.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Describe("Group JSON", func() {
+		It("round trips every field", func() {
+			encoded, err := json.Marshal(expectedGroup)
+			Expect(err).NotTo(HaveOccurred())
+
+			var decoded uaa.Group
+			Expect(json.Unmarshal(encoded, &decoded)).To(Succeed())
+			Expect(decoded).To(Equal(expectedGroup))
+		})
+	})
+})
@@ -0,0 +1,341 @@
+package uaa
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+type IdentityProvidersInterface interface {
+	CreateIdentityProvider(IdentityProvider) (IdentityProvider, error)
+	IdentityProviderByID(string) (IdentityProvider, error)
+	IdentityProviders() ([]IdentityProvider, error)
+	UpdateIdentityProvider(IdentityProvider) (IdentityProvider, error)
+	DeleteIdentityProvider(string) error
+	UpdateIdentityProviderStatus(string, IdentityProviderStatus) (IdentityProviderStatus, error)
+	TestIdentityProvider(IdentityProvider, string, string) error
+}
+
+const (
+	IdentityProviderTypeUAA   = "uaa"
+	IdentityProviderTypeLDAP  = "ldap"
+	IdentityProviderTypeSAML  = "saml"
+	IdentityProviderTypeOIDC  = "oidc1.0"
+	IdentityProviderTypeOAuth = "oauth2.0"
+)
+
+// Source of users for an identity zone, managed through /identity-providers.
+// Only the config matching Type is sent; providers of types without a typed
+// config keep theirs in RawConfig. Providers are created active when Active
+// is nil.
+type IdentityProvider struct {
+	ID             string
+	Type           string
+	OriginKey      string
+	Name           string
+	Version        int
+	Active         *bool
+	IdentityZoneID string
+	Created        int64
+	LastModified   int64
+
+	UAAConfig  *UAAIdentityProviderConfig
+	LDAPConfig *LDAPIdentityProviderConfig
+	SAMLConfig *SAMLIdentityProviderConfig
+	OIDCConfig *OIDCIdentityProviderConfig
+	RawConfig  json.RawMessage
+}
+
+type UAAIdentityProviderConfig struct {
+	PasswordPolicy                *PasswordPolicy `json:"passwordPolicy,omitempty"`
+	LockoutPolicy                 *LockoutPolicy  `json:"lockoutPolicy,omitempty"`
+	DisableInternalUserManagement bool            `json:"disableInternalUserManagement"`
+	EmailDomain                   []string        `json:"emailDomain,omitempty"`
+	ProviderDescription           string          `json:"providerDescription,omitempty"`
+}
+
+type PasswordPolicy struct {
+	MinLength                 int `json:"minLength"`
+	MaxLength                 int `json:"maxLength"`
+	RequireUpperCaseCharacter int `json:"requireUpperCaseCharacter"`
+	RequireLowerCaseCharacter int `json:"requireLowerCaseCharacter"`
+	RequireDigit              int `json:"requireDigit"`
+	RequireSpecialCharacter   int `json:"requireSpecialCharacter"`
+	ExpirePasswordInMonths    int `json:"expirePasswordInMonths"`
+}
+
+type LockoutPolicy struct {
+	LockoutPeriodSeconds int `json:"lockoutPeriodSeconds"`
+	LockoutAfterFailures int `json:"lockoutAfterFailures"`
+	CountFailuresWithin  int `json:"countFailuresWithin"`
+}
+
+// Settings shared by the LDAP, SAML and OIDC configs
+type ExternalIdentityProviderConfig struct {
+	EmailDomain             []string               `json:"emailDomain,omitempty"`
+	ProviderDescription     string                 `json:"providerDescription,omitempty"`
+	AttributeMappings       map[string]interface{} `json:"attributeMappings,omitempty"`
+	ExternalGroupsWhitelist []string               `json:"externalGroupsWhitelist,omitempty"`
+	AddShadowUserOnLogin    bool                   `json:"addShadowUserOnLogin"`
+	StoreCustomAttributes   bool                   `json:"storeCustomAttributes"`
+}
+
+type LDAPIdentityProviderConfig struct {
+	ExternalIdentityProviderConfig
+
+	LDAPProfileFile             string `json:"ldapProfileFile,omitempty"`
+	BaseURL                     string `json:"baseUrl"`
+	SkipSSLVerification         bool   `json:"skipSSLVerification"`
+	TLSConfiguration            string `json:"tlsConfiguration,omitempty"`
+	BindUserDN                  string `json:"bindUserDn,omitempty"`
+	BindPassword                string `json:"bindPassword,omitempty"`
+	UserSearchBase              string `json:"userSearchBase,omitempty"`
+	UserSearchFilter            string `json:"userSearchFilter,omitempty"`
+	UserDNPattern               string `json:"userDNPattern,omitempty"`
+	UserDNPatternDelimiter      string `json:"userDNPatternDelimiter,omitempty"`
+	PasswordAttributeName       string `json:"passwordAttributeName,omitempty"`
+	PasswordEncoder             string `json:"passwordEncoder,omitempty"`
+	MailAttributeName           string `json:"mailAttributeName,omitempty"`
+	MailSubstitute              string `json:"mailSubstitute,omitempty"`
+	MailSubstituteOverridesLDAP bool   `json:"mailSubstituteOverridesLdap"`
+	LDAPGroupFile               string `json:"ldapGroupFile,omitempty"`
+	GroupSearchBase             string `json:"groupSearchBase,omitempty"`
+	GroupSearchFilter           string `json:"groupSearchFilter,omitempty"`
+	GroupsIgnorePartialResults  bool   `json:"groupsIgnorePartialResults"`
+	AutoAddGroups               bool   `json:"autoAddGroups"`
+	GroupSearchSubTree          bool   `json:"groupSearchSubTree"`
+	MaxGroupSearchDepth         int    `json:"maxGroupSearchDepth,omitempty"`
+	GroupRoleAttribute          string `json:"groupRoleAttribute,omitempty"`
+}
+
+type SAMLIdentityProviderConfig struct {
+	ExternalIdentityProviderConfig
+
+	MetaDataLocation       string `json:"metaDataLocation"`
+	IdpEntityAlias         string `json:"idpEntityAlias,omitempty"`
+	ZoneID                 string `json:"zoneId,omitempty"`
+	NameID                 string `json:"nameID,omitempty"`
+	AssertionConsumerIndex int    `json:"assertionConsumerIndex"`
+	MetadataTrustCheck     bool   `json:"metadataTrustCheck"`
+	ShowSAMLLink           bool   `json:"showSamlLink"`
+	LinkText               string `json:"linkText,omitempty"`
+	IconURL                string `json:"iconUrl,omitempty"`
+	GroupMappingMode       string `json:"groupMappingMode,omitempty"`
+	SkipSSLValidation      bool   `json:"skipSslValidation"`
+}
+
+// Config of OpenID Connect providers, which also covers plain OAuth 2.0
+// providers
+type OIDCIdentityProviderConfig struct {
+	ExternalIdentityProviderConfig
+
+	DiscoveryURL             string   `json:"discoveryUrl,omitempty"`
+	AuthURL                  string   `json:"authUrl,omitempty"`
+	TokenURL                 string   `json:"tokenUrl,omitempty"`
+	TokenKeyURL              string   `json:"tokenKeyUrl,omitempty"`
+	TokenKey                 string   `json:"tokenKey,omitempty"`
+	UserInfoURL              string   `json:"userInfoUrl,omitempty"`
+	Issuer                   string   `json:"issuer,omitempty"`
+	RelyingPartyID           string   `json:"relyingPartyId"`
+	RelyingPartySecret       string   `json:"relyingPartySecret,omitempty"`
+	Scopes                   []string `json:"scopes,omitempty"`
+	ResponseType             string   `json:"responseType,omitempty"`
+	LinkText                 string   `json:"linkText,omitempty"`
+	ShowLinkText             bool     `json:"showLinkText"`
+	SkipSSLValidation        bool     `json:"skipSslValidation"`
+	ClientAuthInBody         bool     `json:"clientAuthInBody"`
+	PasswordGrantEnabled     bool     `json:"passwordGrantEnabled"`
+	UserPropagationParameter string   `json:"userPropagationParameter,omitempty"`
+}
+
+type IdentityProviderStatus struct {
+	RequirePasswordChange bool `json:"requirePasswordChange"`
+}
+
+type identityProviderJSON struct {
+	ID             string          `json:"id,omitempty"`
+	Type           string          `json:"type"`
+	OriginKey      string          `json:"originKey"`
+	Name           string          `json:"name"`
+	Version        int             `json:"version"`
+	Active         *bool           `json:"active,omitempty"`
+	IdentityZoneID string          `json:"identityZoneId,omitempty"`
+	Created        int64           `json:"created,omitempty"`
+	LastModified   int64           `json:"last_modified,omitempty"`
+	Config         json.RawMessage `json:"config,omitempty"`
+}
+
+// UAA sends the config as a string holding JSON, so it is encoded that way
+func (provider IdentityProvider) MarshalJSON() ([]byte, error) {
+	encoded := identityProviderJSON{
+		ID:             provider.ID,
+		Type:           provider.Type,
+		OriginKey:      provider.OriginKey,
+		Name:           provider.Name,
+		Version:        provider.Version,
+		Active:         provider.Active,
+		IdentityZoneID: provider.IdentityZoneID,
+		Created:        provider.Created,
+		LastModified:   provider.LastModified,
+	}
+
+	config := provider.RawConfig
+	if typed := provider.typedConfig(); typed != nil {
+		var err error
+		config, err = json.Marshal(typed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(config) != 0 {
+		quoted, err := json.Marshal(string(config))
+		if err != nil {
+			return nil, err
+		}
+		encoded.Config = quoted
+	}
+
+	return json.Marshal(encoded)
+}
+
+// Accepts the config both as a string holding JSON and as a JSON object, as
+// UAA sends it when asked for the raw config
+func (provider *IdentityProvider) UnmarshalJSON(data []byte) error {
+	var decoded identityProviderJSON
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+
+	*provider = IdentityProvider{
+		ID:             decoded.ID,
+		Type:           decoded.Type,
+		OriginKey:      decoded.OriginKey,
+		Name:           decoded.Name,
+		Version:        decoded.Version,
+		Active:         decoded.Active,
+		IdentityZoneID: decoded.IdentityZoneID,
+		Created:        decoded.Created,
+		LastModified:   decoded.LastModified,
+	}
+
+	config := decoded.Config
+	var text string
+	if json.Unmarshal(config, &text) == nil {
+		config = json.RawMessage(text)
+	}
+	if len(config) == 0 || string(config) == "null" {
+		return nil
+	}
+
+	switch provider.Type {
+	case IdentityProviderTypeUAA:
+		provider.UAAConfig = &UAAIdentityProviderConfig{}
+		return json.Unmarshal(config, provider.UAAConfig)
+	case IdentityProviderTypeLDAP:
+		provider.LDAPConfig = &LDAPIdentityProviderConfig{}
+		return json.Unmarshal(config, provider.LDAPConfig)
+	case IdentityProviderTypeSAML:
+		provider.SAMLConfig = &SAMLIdentityProviderConfig{}
+		return json.Unmarshal(config, provider.SAMLConfig)
+	case IdentityProviderTypeOIDC, IdentityProviderTypeOAuth:
+		provider.OIDCConfig = &OIDCIdentityProviderConfig{}
+		return json.Unmarshal(config, provider.OIDCConfig)
+	default:
+		provider.RawConfig = config
+		return nil
+	}
+}
+
+func (provider IdentityProvider) typedConfig() interface{} {
+	switch provider.Type {
+	case IdentityProviderTypeUAA:
+		if provider.UAAConfig != nil {
+			return provider.UAAConfig
+		}
+	case IdentityProviderTypeLDAP:
+		if provider.LDAPConfig != nil {
+			return provider.LDAPConfig
+		}
+	case IdentityProviderTypeSAML:
+		if provider.SAMLConfig != nil {
+			return provider.SAMLConfig
+		}
+	case IdentityProviderTypeOIDC, IdentityProviderTypeOAuth:
+		if provider.OIDCConfig != nil {
+			return provider.OIDCConfig
+		}
+	}
+	return nil
+}
+
+func CreateIdentityProvider(u UAA, provider IdentityProvider) (_ IdentityProvider, err error) {
+	u, span := u.withOperation("CreateIdentityProvider")
+	defer span.end(&err)
+
+	var created IdentityProvider
+	err = u.adminRequest("POST", "/identity-providers", nil, provider, &created)
+	return created, err
+}
+
+func IdentityProviderByID(u UAA, id string) (_ IdentityProvider, err error) {
+	u, span := u.withOperation("IdentityProviderByID")
+	defer span.end(&err)
+
+	var provider IdentityProvider
+	err = u.adminRequest("GET", "/identity-providers/"+url.PathEscape(id), nil, nil, &provider)
+	return provider, err
+}
+
+func IdentityProviders(u UAA) (_ []IdentityProvider, err error) {
+	u, span := u.withOperation("IdentityProviders")
+	defer span.end(&err)
+
+	providers := []IdentityProvider{}
+	err = u.adminRequest("GET", "/identity-providers", nil, nil, &providers)
+	return providers, err
+}
+
+func UpdateIdentityProvider(u UAA, provider IdentityProvider) (_ IdentityProvider, err error) {
+	u, span := u.withOperation("UpdateIdentityProvider")
+	defer span.end(&err)
+
+	var updated IdentityProvider
+	err = u.adminRequest("PUT", "/identity-providers/"+url.PathEscape(provider.ID), nil, provider, &updated)
+	return updated, err
+}
+
+func DeleteIdentityProvider(u UAA, id string) (err error) {
+	u, span := u.withOperation("DeleteIdentityProvider")
+	defer span.end(&err)
+
+	return u.adminRequest("DELETE", "/identity-providers/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// Forces the users of the provider to change their password on their next
+// login. Only applies to the uaa provider.
+func UpdateIdentityProviderStatus(u UAA, id string, status IdentityProviderStatus) (_ IdentityProviderStatus, err error) {
+	u, span := u.withOperation("UpdateIdentityProviderStatus")
+	defer span.end(&err)
+
+	var updated IdentityProviderStatus
+	err = u.adminRequest("PATCH", "/identity-providers/"+url.PathEscape(id)+"/status", nil, status, &updated)
+	return updated, err
+}
+
+// Checks that UAA can authenticate the given user against an LDAP or OIDC
+// provider definition, without saving it. Returns a Failure when it cannot.
+func TestIdentityProvider(u UAA, provider IdentityProvider, username, password string) (err error) {
+	u, span := u.withOperation("TestIdentityProvider")
+	defer span.end(&err)
+
+	request := map[string]interface{}{
+		"provider": provider,
+		"credentials": map[string]string{
+			"username": username,
+			"password": password,
+		},
+	}
+
+	return u.adminRequest("POST", "/identity-providers/test", nil, request, nil)
+}
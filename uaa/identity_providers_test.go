@@ -0,0 +1,261 @@
+package uaa_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const uaaIdentityProviderJSON = `{
+  "type": "uaa",
+  "config": "{\"emailDomain\":null,\"providerDescription\":null,\"passwordPolicy\":{\"minLength\":6,\"maxLength\":128,\"requireUpperCaseCharacter\":1,\"requireLowerCaseCharacter\":1,\"requireDigit\":1,\"requireSpecialCharacter\":0,\"expirePasswordInMonths\":3},\"lockoutPolicy\":{\"lockoutPeriodSeconds\":300,\"lockoutAfterFailures\":5,\"countFailuresWithin\":3600},\"disableInternalUserManagement\":false}",
+  "id": "uaa-provider-id",
+  "originKey": "uaa",
+  "name": "uaa",
+  "version": 1,
+  "created": 946710000000,
+  "last_modified": 1513708000000,
+  "active": true,
+  "identityZoneId": "uaa"
+}`
+
+const ldapIdentityProviderJSON = `{
+  "type": "ldap",
+  "config": {
+    "emailDomain": ["example.com"],
+    "attributeMappings": {"given_name": "givenname"},
+    "externalGroupsWhitelist": ["admins"],
+    "addShadowUserOnLogin": true,
+    "storeCustomAttributes": false,
+    "ldapProfileFile": "ldap/ldap-search-and-bind.xml",
+    "baseUrl": "ldap://localhost:389/",
+    "skipSSLVerification": false,
+    "bindUserDn": "cn=admin,dc=test,dc=com",
+    "bindPassword": "adminsecret",
+    "userSearchBase": "dc=test,dc=com",
+    "userSearchFilter": "cn={0}",
+    "mailAttributeName": "mail",
+    "mailSubstituteOverridesLdap": false,
+    "ldapGroupFile": "ldap/ldap-groups-map-to-scopes.xml",
+    "groupSearchBase": "ou=scopes,dc=test,dc=com",
+    "groupSearchFilter": "member={0}",
+    "groupsIgnorePartialResults": false,
+    "autoAddGroups": true,
+    "groupSearchSubTree": true,
+    "maxGroupSearchDepth": 10
+  },
+  "id": "ldap-provider-id",
+  "originKey": "ldap",
+  "name": "LDAP",
+  "version": 0,
+  "active": true,
+  "identityZoneId": "uaa"
+}`
+
+var _ = Describe("IdentityProviders", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var requests []*http.Request
+	var requestBodies []string
+
+	BeforeEach(func() {
+		requests = []*http.Request{}
+		requestBodies = []string{}
+		fakeUAAServer = httptest.NewServer(recordRequests(&requests, &requestBodies, func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "Bearer my-special-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			switch {
+			case req.URL.Path == "/identity-providers" && req.Method == "POST":
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(ldapIdentityProviderJSON))
+			case req.URL.Path == "/identity-providers" && req.Method == "GET":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("[" + uaaIdentityProviderJSON + "," + ldapIdentityProviderJSON + "]"))
+			case req.URL.Path == "/identity-providers/uaa-provider-id":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(uaaIdentityProviderJSON))
+			case req.URL.Path == "/identity-providers/uaa-provider-id/status":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"requirePasswordChange":true}`))
+			case req.URL.Path == "/identity-providers/test":
+				body, _ := ioutil.ReadAll(req.Body)
+				if string(body) == "" || !json.Valid(body) {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				var test struct {
+					Credentials map[string]string `json:"credentials"`
+				}
+				json.Unmarshal(body, &test)
+				if test.Credentials["password"] != "marissa" {
+					w.WriteHeader(http.StatusExpectationFailed)
+					w.Write([]byte("bad credentials"))
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("ok"))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error_description":"Provider not found","error":"not_found"}`))
+			}
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	It("decodes configs sent as strings into the typed config for the provider type", func() {
+		provider, err := uaa.IdentityProviderByID(auth, "uaa-provider-id")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(provider.OriginKey).To(Equal("uaa"))
+		Expect(provider.IdentityZoneID).To(Equal("uaa"))
+		Expect(provider.LastModified).To(Equal(int64(1513708000000)))
+		Expect(provider.LDAPConfig).To(BeNil())
+		Expect(provider.UAAConfig.PasswordPolicy).To(Equal(&uaa.PasswordPolicy{
+			MinLength:                 6,
+			MaxLength:                 128,
+			RequireUpperCaseCharacter: 1,
+			RequireLowerCaseCharacter: 1,
+			RequireDigit:              1,
+			ExpirePasswordInMonths:    3,
+		}))
+		Expect(provider.UAAConfig.LockoutPolicy.LockoutAfterFailures).To(Equal(5))
+	})
+
+	It("decodes configs sent as objects", func() {
+		providers, err := uaa.IdentityProviders(auth)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(providers).To(HaveLen(2))
+		ldap := providers[1].LDAPConfig
+		Expect(ldap.BaseURL).To(Equal("ldap://localhost:389/"))
+		Expect(ldap.BindPassword).To(Equal("adminsecret"))
+		Expect(ldap.EmailDomain).To(Equal([]string{"example.com"}))
+		Expect(ldap.AttributeMappings).To(Equal(map[string]interface{}{"given_name": "givenname"}))
+		Expect(ldap.AddShadowUserOnLogin).To(BeTrue())
+		Expect(ldap.MaxGroupSearchDepth).To(Equal(10))
+	})
+
+	It("creates providers with their config encoded as a string", func() {
+		created, err := uaa.CreateIdentityProvider(auth, uaa.IdentityProvider{
+			Type:      uaa.IdentityProviderTypeOIDC,
+			OriginKey: "my-oidc-provider",
+			Name:      "My OIDC Provider",
+			Active:    boolPointer(true),
+			OIDCConfig: &uaa.OIDCIdentityProviderConfig{
+				DiscoveryURL:       "https://accounts.example.com/.well-known/openid-configuration",
+				RelyingPartyID:     "uaa",
+				RelyingPartySecret: "secret",
+				Scopes:             []string{"openid", "email"},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created.ID).To(Equal("ldap-provider-id"))
+
+		var sent map[string]interface{}
+		Expect(json.Unmarshal([]byte(requestBodies[0]), &sent)).To(Succeed())
+		Expect(sent["type"]).To(Equal("oidc1.0"))
+		Expect(sent["originKey"]).To(Equal("my-oidc-provider"))
+		Expect(sent["active"]).To(Equal(true))
+		Expect(sent["config"]).To(MatchJSON(`{
+            "discoveryUrl": "https://accounts.example.com/.well-known/openid-configuration",
+            "relyingPartyId": "uaa",
+            "relyingPartySecret": "secret",
+            "scopes": ["openid", "email"],
+            "addShadowUserOnLogin": false,
+            "storeCustomAttributes": false,
+            "showLinkText": false,
+            "skipSslValidation": false,
+            "clientAuthInBody": false,
+            "passwordGrantEnabled": false
+        }`))
+	})
+
+	It("leaves out Active when it is not set, for UAA to create an active provider", func() {
+		_, err := uaa.CreateIdentityProvider(auth, uaa.IdentityProvider{
+			Type:      uaa.IdentityProviderTypeOIDC,
+			OriginKey: "my-oidc-provider",
+			Name:      "My OIDC Provider",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var sent map[string]interface{}
+		Expect(json.Unmarshal([]byte(requestBodies[0]), &sent)).To(Succeed())
+		Expect(sent).NotTo(HaveKey("active"))
+	})
+
+	It("keeps configs of providers without a typed config", func() {
+		var provider uaa.IdentityProvider
+		err := json.Unmarshal([]byte(`{"type":"keystone","originKey":"keystone","config":"{\"baseUrl\":\"http://keystone\"}"}`), &provider)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(provider.RawConfig).To(MatchJSON(`{"baseUrl":"http://keystone"}`))
+
+		encoded, err := json.Marshal(provider)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(encoded).To(MatchJSON(`{"type":"keystone","originKey":"keystone","name":"","version":0,"config":"{\"baseUrl\":\"http://keystone\"}"}`))
+	})
+
+	It("updates providers", func() {
+		provider, err := uaa.IdentityProviderByID(auth, "uaa-provider-id")
+		Expect(err).NotTo(HaveOccurred())
+
+		provider.UAAConfig.PasswordPolicy.MinLength = 12
+		_, err = uaa.UpdateIdentityProvider(auth, provider)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests[1].Method).To(Equal("PUT"))
+		Expect(requests[1].URL.Path).To(Equal("/identity-providers/uaa-provider-id"))
+		Expect(requestBodies[1]).To(ContainSubstring(`\"minLength\":12`))
+	})
+
+	It("deletes providers", func() {
+		err := uaa.DeleteIdentityProvider(auth, "uaa-provider-id")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests[0].Method).To(Equal("DELETE"))
+
+		err = uaa.DeleteIdentityProvider(auth, "missing")
+		Expect(uaa.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("updates the status of providers", func() {
+		status, err := uaa.UpdateIdentityProviderStatus(auth, "uaa-provider-id", uaa.IdentityProviderStatus{RequirePasswordChange: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests[0].Method).To(Equal("PATCH"))
+		Expect(requestBodies[0]).To(MatchJSON(`{"requirePasswordChange":true}`))
+		Expect(status.RequirePasswordChange).To(BeTrue())
+	})
+
+	It("tests provider definitions with the given credentials", func() {
+		provider := uaa.IdentityProvider{
+			Type:       uaa.IdentityProviderTypeLDAP,
+			OriginKey:  "ldap",
+			LDAPConfig: &uaa.LDAPIdentityProviderConfig{BaseURL: "ldap://localhost:389/"},
+		}
+
+		err := uaa.TestIdentityProvider(auth, provider, "marissa", "marissa")
+		Expect(err).NotTo(HaveOccurred())
+
+		var sent map[string]interface{}
+		Expect(json.Unmarshal([]byte(requestBodies[0]), &sent)).To(Succeed())
+		Expect(sent["credentials"]).To(Equal(map[string]interface{}{"username": "marissa", "password": "marissa"}))
+		Expect(sent["provider"]).To(HaveKeyWithValue("type", "ldap"))
+
+		err = uaa.TestIdentityProvider(auth, provider, "marissa", "wrong")
+		Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+		Expect(err.(uaa.Failure).Code()).To(Equal(http.StatusExpectationFailed))
+		Expect(err.(uaa.Failure).Message()).To(Equal("bad credentials"))
+	})
+})
@@ -0,0 +1,212 @@
+package uaa
+
+import (
+	"net/url"
+)
+
+type IdentityZonesInterface interface {
+	CreateIdentityZone(IdentityZone) (IdentityZone, error)
+	IdentityZoneByID(string) (IdentityZone, error)
+	IdentityZones() ([]IdentityZone, error)
+	UpdateIdentityZone(IdentityZone) (IdentityZone, error)
+	DeleteIdentityZone(string) error
+}
+
+// Tenant of a multi-tenant UAA, managed through /identity-zones. Created and
+// LastModified are in milliseconds since the epoch. Zones are created active
+// when Active is nil.
+type IdentityZone struct {
+	ID           string             `json:"id,omitempty"`
+	Subdomain    string             `json:"subdomain"`
+	Name         string             `json:"name"`
+	Description  string             `json:"description,omitempty"`
+	Version      int                `json:"version,omitempty"`
+	Active       *bool              `json:"active,omitempty"`
+	Created      int64              `json:"created,omitempty"`
+	LastModified int64              `json:"last_modified,omitempty"`
+	Config       IdentityZoneConfig `json:"config"`
+}
+
+type IdentityZoneConfig struct {
+	ClientSecretPolicy      *ClientSecretPolicy `json:"clientSecretPolicy,omitempty"`
+	TokenPolicy             *TokenPolicy        `json:"tokenPolicy,omitempty"`
+	SAMLConfig              *ZoneSAMLConfig     `json:"samlConfig,omitempty"`
+	CORSPolicy              *CORSPolicy         `json:"corsPolicy,omitempty"`
+	Links                   *ZoneLinks          `json:"links,omitempty"`
+	Prompts                 []Prompt            `json:"prompts,omitempty"`
+	IdpDiscoveryEnabled     bool                `json:"idpDiscoveryEnabled"`
+	AccountChooserEnabled   bool                `json:"accountChooserEnabled"`
+	Branding                *Branding           `json:"branding,omitempty"`
+	UserConfig              *ZoneUserConfig     `json:"userConfig,omitempty"`
+	MFAConfig               *MFAConfig          `json:"mfaConfig,omitempty"`
+	Issuer                  string              `json:"issuer,omitempty"`
+	DefaultIdentityProvider string              `json:"defaultIdentityProvider,omitempty"`
+}
+
+type ClientSecretPolicy struct {
+	MinLength                 int `json:"minLength"`
+	MaxLength                 int `json:"maxLength"`
+	RequireUpperCaseCharacter int `json:"requireUpperCaseCharacter"`
+	RequireLowerCaseCharacter int `json:"requireLowerCaseCharacter"`
+	RequireDigit              int `json:"requireDigit"`
+	RequireSpecialCharacter   int `json:"requireSpecialCharacter"`
+}
+
+// Token validities are in seconds, -1 uses the UAA defaults
+type TokenPolicy struct {
+	AccessTokenValidity  int                 `json:"accessTokenValidity"`
+	RefreshTokenValidity int                 `json:"refreshTokenValidity"`
+	JWTRevocable         bool                `json:"jwtRevocable"`
+	RefreshTokenUnique   bool                `json:"refreshTokenUnique"`
+	RefreshTokenFormat   string              `json:"refreshTokenFormat,omitempty"`
+	ActiveKeyID          string              `json:"activeKeyId,omitempty"`
+	Keys                 map[string]TokenKey `json:"keys,omitempty"`
+}
+
+type TokenKey struct {
+	SigningKey string `json:"signingKey"`
+}
+
+type ZoneSAMLConfig struct {
+	AssertionSigned            bool               `json:"assertionSigned"`
+	RequestSigned              bool               `json:"requestSigned"`
+	WantAssertionSigned        bool               `json:"wantAssertionSigned"`
+	WantAuthnRequestSigned     bool               `json:"wantAuthnRequestSigned"`
+	AssertionTimeToLiveSeconds int                `json:"assertionTimeToLiveSeconds"`
+	EntityID                   string             `json:"entityID,omitempty"`
+	DisableInResponseToCheck   bool               `json:"disableInResponseToCheck"`
+	ActiveKeyID                string             `json:"activeKeyId,omitempty"`
+	Keys                       map[string]SAMLKey `json:"keys,omitempty"`
+}
+
+type SAMLKey struct {
+	Key         string `json:"key,omitempty"`
+	Passphrase  string `json:"passphrase,omitempty"`
+	Certificate string `json:"certificate"`
+}
+
+type CORSPolicy struct {
+	XHRConfiguration     CORSConfiguration `json:"xhrConfiguration"`
+	DefaultConfiguration CORSConfiguration `json:"defaultConfiguration"`
+}
+
+type CORSConfiguration struct {
+	AllowedOrigins        []string `json:"allowedOrigins"`
+	AllowedOriginPatterns []string `json:"allowedOriginPatterns"`
+	AllowedURIs           []string `json:"allowedUris"`
+	AllowedURIPatterns    []string `json:"allowedUriPatterns"`
+	AllowedHeaders        []string `json:"allowedHeaders"`
+	AllowedMethods        []string `json:"allowedMethods"`
+	AllowedCredentials    bool     `json:"allowedCredentials"`
+	MaxAge                int      `json:"maxAge"`
+}
+
+type ZoneLinks struct {
+	Logout       *LogoutLinks      `json:"logout,omitempty"`
+	HomeRedirect string            `json:"homeRedirect,omitempty"`
+	SelfService  *SelfServiceLinks `json:"selfService,omitempty"`
+}
+
+type LogoutLinks struct {
+	RedirectURL              string   `json:"redirectUrl,omitempty"`
+	RedirectParameterName    string   `json:"redirectParameterName,omitempty"`
+	DisableRedirectParameter bool     `json:"disableRedirectParameter"`
+	Whitelist                []string `json:"whitelist,omitempty"`
+}
+
+type SelfServiceLinks struct {
+	SelfServiceLinksEnabled bool   `json:"selfServiceLinksEnabled"`
+	Signup                  string `json:"signup,omitempty"`
+	Passwd                  string `json:"passwd,omitempty"`
+}
+
+type Prompt struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type Branding struct {
+	CompanyName     string            `json:"companyName,omitempty"`
+	ProductLogo     string            `json:"productLogo,omitempty"`
+	SquareLogo      string            `json:"squareLogo,omitempty"`
+	FooterLegalText string            `json:"footerLegalText,omitempty"`
+	FooterLinks     map[string]string `json:"footerLinks,omitempty"`
+	Banner          *Banner           `json:"banner,omitempty"`
+	Consent         *Consent          `json:"consent,omitempty"`
+}
+
+type Banner struct {
+	Text            string `json:"text,omitempty"`
+	Logo            string `json:"logo,omitempty"`
+	Link            string `json:"link,omitempty"`
+	TextColor       string `json:"textColor,omitempty"`
+	BackgroundColor string `json:"backgroundColor,omitempty"`
+}
+
+type Consent struct {
+	Text string `json:"text,omitempty"`
+	Link string `json:"link,omitempty"`
+}
+
+type ZoneUserConfig struct {
+	DefaultGroups []string `json:"defaultGroups,omitempty"`
+	AllowedGroups []string `json:"allowedGroups,omitempty"`
+}
+
+type MFAConfig struct {
+	Enabled           bool     `json:"enabled"`
+	ProviderName      string   `json:"providerName,omitempty"`
+	IdentityProviders []string `json:"identityProviders,omitempty"`
+}
+
+func CreateIdentityZone(u UAA, zone IdentityZone) (_ IdentityZone, err error) {
+	u, span := u.withOperation("CreateIdentityZone")
+	defer span.end(&err)
+
+	var created IdentityZone
+	err = u.withoutIdentityZone().adminRequest("POST", "/identity-zones", nil, zone, &created)
+	return created, err
+}
+
+func IdentityZoneByID(u UAA, id string) (_ IdentityZone, err error) {
+	u, span := u.withOperation("IdentityZoneByID")
+	defer span.end(&err)
+
+	var zone IdentityZone
+	err = u.withoutIdentityZone().adminRequest("GET", "/identity-zones/"+url.PathEscape(id), nil, nil, &zone)
+	return zone, err
+}
+
+func IdentityZones(u UAA) (_ []IdentityZone, err error) {
+	u, span := u.withOperation("IdentityZones")
+	defer span.end(&err)
+
+	zones := []IdentityZone{}
+	err = u.withoutIdentityZone().adminRequest("GET", "/identity-zones", nil, nil, &zones)
+	return zones, err
+}
+
+func UpdateIdentityZone(u UAA, zone IdentityZone) (_ IdentityZone, err error) {
+	u, span := u.withOperation("UpdateIdentityZone")
+	defer span.end(&err)
+
+	var updated IdentityZone
+	err = u.withoutIdentityZone().adminRequest("PUT", "/identity-zones/"+url.PathEscape(zone.ID), nil, zone, &updated)
+	return updated, err
+}
+
+func DeleteIdentityZone(u UAA, id string) (err error) {
+	u, span := u.withOperation("DeleteIdentityZone")
+	defer span.end(&err)
+
+	return u.withoutIdentityZone().adminRequest("DELETE", "/identity-zones/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// Identity zones are managed from the zone the access token was issued in,
+// so their calls are not switched to the UAA's identity zone
+func (u UAA) withoutIdentityZone() UAA {
+	u.IdentityZoneID = ""
+	u.IdentityZoneSubdomain = ""
+	return u
+}
@@ -0,0 +1,322 @@
+package uaa_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const identityZoneJSON = `{
+  "id": "twiglet",
+  "subdomain": "twiglet",
+  "config": {
+    "clientSecretPolicy": {
+      "minLength": 8,
+      "maxLength": 128,
+      "requireUpperCaseCharacter": 1,
+      "requireLowerCaseCharacter": 1,
+      "requireDigit": 1,
+      "requireSpecialCharacter": 0
+    },
+    "tokenPolicy": {
+      "accessTokenValidity": 3600,
+      "refreshTokenValidity": 7200,
+      "jwtRevocable": false,
+      "refreshTokenUnique": false,
+      "refreshTokenFormat": "jwt",
+      "activeKeyId": "active-key-1",
+      "keys": {
+        "active-key-1": {
+          "signingKey": "key"
+        }
+      }
+    },
+    "samlConfig": {
+      "assertionSigned": true,
+      "requestSigned": true,
+      "wantAssertionSigned": true,
+      "wantAuthnRequestSigned": false,
+      "assertionTimeToLiveSeconds": 600,
+      "activeKeyId": "legacy-saml-key",
+      "keys": {
+        "legacy-saml-key": {
+          "certificate": "-----BEGIN CERTIFICATE-----"
+        }
+      },
+      "disableInResponseToCheck": true
+    },
+    "corsPolicy": {
+      "xhrConfiguration": {
+        "allowedOrigins": [".*"],
+        "allowedOriginPatterns": [],
+        "allowedUris": [".*"],
+        "allowedUriPatterns": [],
+        "allowedHeaders": ["Accept", "Authorization", "Content-Type"],
+        "allowedMethods": ["GET"],
+        "allowedCredentials": false,
+        "maxAge": 1728000
+      },
+      "defaultConfiguration": {
+        "allowedOrigins": [".*"],
+        "allowedOriginPatterns": [],
+        "allowedUris": [".*"],
+        "allowedUriPatterns": [],
+        "allowedHeaders": ["Accept", "Authorization", "Content-Type"],
+        "allowedMethods": ["GET"],
+        "allowedCredentials": false,
+        "maxAge": 1728000
+      }
+    },
+    "links": {
+      "logout": {
+        "redirectUrl": "/login",
+        "redirectParameterName": "redirect",
+        "disableRedirectParameter": false,
+        "whitelist": ["http://example.com"]
+      },
+      "homeRedirect": "http://my.hosted.homepage.com/",
+      "selfService": {
+        "selfServiceLinksEnabled": true,
+        "signup": "/create_account",
+        "passwd": "/forgot_password"
+      }
+    },
+    "prompts": [
+      {"name": "username", "type": "text", "text": "Email"},
+      {"name": "password", "type": "password", "text": "Password"}
+    ],
+    "idpDiscoveryEnabled": true,
+    "branding": {
+      "companyName": "Twiglet Inc",
+      "productLogo": "VGVzdFByb2R1Y3RMb2dv",
+      "footerLegalText": "Legal Text",
+      "footerLinks": {"Terms of Use": "/terms"},
+      "banner": {"text": "Announcement", "textColor": "#000000", "backgroundColor": "#89cff0"},
+      "consent": {"text": "Terms and Conditions", "link": "http://example.com/terms"}
+    },
+    "accountChooserEnabled": true,
+    "userConfig": {"defaultGroups": ["openid", "password.write"]},
+    "mfaConfig": {"enabled": true, "providerName": "mfaprovider", "identityProviders": ["uaa", "ldap"]},
+    "issuer": "http://twiglet.localhost:8080/uaa",
+    "defaultIdentityProvider": "uaa"
+  },
+  "name": "The Twiglet Zone",
+  "version": 2,
+  "description": "Like the Twilight Zone but tastier.",
+  "created": 1513708000000,
+  "last_modified": 1513708000001,
+  "active": true
+}`
+
+var _ = Describe("IdentityZones", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var requests []*http.Request
+	var requestBodies []string
+
+	Context("when UAA is responding normally", func() {
+		BeforeEach(func() {
+			requests = []*http.Request{}
+			requestBodies = []string{}
+			fakeUAAServer = httptest.NewServer(recordRequests(&requests, &requestBodies, func(w http.ResponseWriter, req *http.Request) {
+				if !strings.Contains(req.Header.Get("Authorization"), "Bearer my-special-token") {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				switch {
+				case req.URL.Path == "/identity-zones" && req.Method == "POST":
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(identityZoneJSON))
+				case req.URL.Path == "/identity-zones" && req.Method == "GET":
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte("[" + identityZoneJSON + "]"))
+				case req.URL.Path == "/identity-zones/twiglet" && (req.Method == "GET" || req.Method == "PUT" || req.Method == "DELETE"):
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(identityZoneJSON))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+		})
+
+		AfterEach(func() {
+			fakeUAAServer.Close()
+		})
+
+		It("creates identity zones", func() {
+			zone, err := uaa.CreateIdentityZone(auth, uaa.IdentityZone{
+				ID:        "twiglet",
+				Subdomain: "twiglet",
+				Name:      "The Twiglet Zone",
+				Active:    boolPointer(true),
+				Config: uaa.IdentityZoneConfig{
+					TokenPolicy: &uaa.TokenPolicy{AccessTokenValidity: 3600, RefreshTokenValidity: 7200},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(requestBodies[0]).To(MatchJSON(`{
+                "id": "twiglet",
+                "subdomain": "twiglet",
+                "name": "The Twiglet Zone",
+                "active": true,
+                "config": {
+                  "tokenPolicy": {"accessTokenValidity": 3600, "refreshTokenValidity": 7200, "jwtRevocable": false, "refreshTokenUnique": false},
+                  "idpDiscoveryEnabled": false,
+                  "accountChooserEnabled": false
+                }
+            }`))
+			Expect(zone.ID).To(Equal("twiglet"))
+			Expect(zone.Version).To(Equal(2))
+		})
+
+		It("leaves out Active when it is not set, for UAA to create an active zone", func() {
+			_, err := uaa.CreateIdentityZone(auth, uaa.IdentityZone{ID: "twiglet", Subdomain: "twiglet", Name: "The Twiglet Zone"})
+			Expect(err).NotTo(HaveOccurred())
+
+			var sent map[string]interface{}
+			Expect(json.Unmarshal([]byte(requestBodies[0]), &sent)).To(Succeed())
+			Expect(sent).NotTo(HaveKey("active"))
+		})
+
+		It("sends inactive zones as such", func() {
+			_, err := uaa.CreateIdentityZone(auth, uaa.IdentityZone{ID: "twiglet", Subdomain: "twiglet", Name: "The Twiglet Zone", Active: boolPointer(false)})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requestBodies[0]).To(ContainSubstring(`"active":false`))
+		})
+
+		It("returns an identity zone with its whole configuration", func() {
+			zone, err := uaa.IdentityZoneByID(auth, "twiglet")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Method).To(Equal("GET"))
+			Expect(zone.Name).To(Equal("The Twiglet Zone"))
+			Expect(zone.Description).To(Equal("Like the Twilight Zone but tastier."))
+			Expect(*zone.Active).To(BeTrue())
+			Expect(zone.Created).To(Equal(int64(1513708000000)))
+			Expect(zone.LastModified).To(Equal(int64(1513708000001)))
+
+			config := zone.Config
+			Expect(config.ClientSecretPolicy.MinLength).To(Equal(8))
+			Expect(config.TokenPolicy.AccessTokenValidity).To(Equal(3600))
+			Expect(config.TokenPolicy.Keys["active-key-1"].SigningKey).To(Equal("key"))
+			Expect(config.SAMLConfig.AssertionTimeToLiveSeconds).To(Equal(600))
+			Expect(config.SAMLConfig.Keys["legacy-saml-key"].Certificate).To(Equal("-----BEGIN CERTIFICATE-----"))
+			Expect(config.CORSPolicy.XHRConfiguration.AllowedMethods).To(Equal([]string{"GET"}))
+			Expect(config.CORSPolicy.DefaultConfiguration.MaxAge).To(Equal(1728000))
+			Expect(config.Links.Logout.Whitelist).To(Equal([]string{"http://example.com"}))
+			Expect(config.Links.SelfService.Signup).To(Equal("/create_account"))
+			Expect(config.Links.HomeRedirect).To(Equal("http://my.hosted.homepage.com/"))
+			Expect(config.Prompts).To(ContainElement(uaa.Prompt{Name: "password", Type: "password", Text: "Password"}))
+			Expect(config.IdpDiscoveryEnabled).To(BeTrue())
+			Expect(config.AccountChooserEnabled).To(BeTrue())
+			Expect(config.Branding.CompanyName).To(Equal("Twiglet Inc"))
+			Expect(config.Branding.FooterLinks).To(Equal(map[string]string{"Terms of Use": "/terms"}))
+			Expect(config.Branding.Banner.BackgroundColor).To(Equal("#89cff0"))
+			Expect(config.Branding.Consent.Link).To(Equal("http://example.com/terms"))
+			Expect(config.UserConfig.DefaultGroups).To(Equal([]string{"openid", "password.write"}))
+			Expect(config.MFAConfig).To(Equal(&uaa.MFAConfig{Enabled: true, ProviderName: "mfaprovider", IdentityProviders: []string{"uaa", "ldap"}}))
+			Expect(config.Issuer).To(Equal("http://twiglet.localhost:8080/uaa"))
+			Expect(config.DefaultIdentityProvider).To(Equal("uaa"))
+		})
+
+		It("round trips identity zones through JSON", func() {
+			zone, err := uaa.IdentityZoneByID(auth, "twiglet")
+			Expect(err).NotTo(HaveOccurred())
+
+			encoded, err := json.Marshal(zone)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(encoded).To(MatchJSON(identityZoneJSON))
+		})
+
+		It("lists identity zones", func() {
+			zones, err := uaa.IdentityZones(auth)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(zones).To(HaveLen(1))
+			Expect(zones[0].Subdomain).To(Equal("twiglet"))
+		})
+
+		It("updates identity zones", func() {
+			zone, err := uaa.IdentityZoneByID(auth, "twiglet")
+			Expect(err).NotTo(HaveOccurred())
+
+			zone.Name = "The Renamed Zone"
+			_, err = uaa.UpdateIdentityZone(auth, zone)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[1].Method).To(Equal("PUT"))
+			Expect(requests[1].URL.Path).To(Equal("/identity-zones/twiglet"))
+			Expect(requestBodies[1]).To(ContainSubstring(`"name":"The Renamed Zone"`))
+		})
+
+		It("deletes identity zones", func() {
+			err := uaa.DeleteIdentityZone(auth, "twiglet")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Method).To(Equal("DELETE"))
+			Expect(requests[0].URL.Path).To(Equal("/identity-zones/twiglet"))
+		})
+
+		It("manages identity zones from the calling zone", func() {
+			zoned := auth.WithIdentityZoneID("other-zone")
+
+			_, err := uaa.IdentityZones(zoned)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = uaa.IdentityZoneByID(zoned.WithIdentityZoneSubdomain("other"), "twiglet")
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, request := range requests {
+				Expect(request.Header).NotTo(HaveKey("X-Identity-Zone-Id"))
+				Expect(request.Header).NotTo(HaveKey("X-Identity-Zone-Subdomain"))
+			}
+			Expect(requests).To(HaveLen(2))
+		})
+	})
+
+	Context("when UAA is not responding normally", func() {
+		BeforeEach(func() {
+			fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/identity-zones" && req.Method == "POST" {
+					w.WriteHeader(http.StatusConflict)
+					w.Write([]byte(`{"error_description":"The identity zone subdomain is taken.","error":"invalid_identity_zone"}`))
+				} else {
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"error_description":"Zone does not exist","error":"not_found"}`))
+				}
+			}))
+			auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+		})
+
+		AfterEach(func() {
+			fakeUAAServer.Close()
+		})
+
+		It("returns structured failures", func() {
+			_, err := uaa.CreateIdentityZone(auth, uaa.IdentityZone{Subdomain: "twiglet"})
+			Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+			Expect(uaa.IsConflict(err)).To(BeTrue())
+			Expect(err.(uaa.Failure).ErrorDescription()).To(Equal("The identity zone subdomain is taken."))
+
+			_, err = uaa.IdentityZoneByID(auth, "missing")
+			Expect(uaa.IsNotFound(err)).To(BeTrue())
+
+			err = uaa.DeleteIdentityZone(auth, "missing")
+			Expect(uaa.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
+
+func boolPointer(value bool) *bool {
+	return &value
+}
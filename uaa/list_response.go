@@ -0,0 +1,152 @@
+package uaa
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Wrapped by the errors returned for responses that are not what UAA
+// documents, use errors.Is to detect it
+var MalformedResponseError = errors.New("Malformed UAA response")
+
+// Paging information of a SCIM list response
+type listResponse struct {
+	StartIndex   int
+	ItemsPerPage int
+	TotalResults int
+}
+
+// Parameters of a SCIM list request, zero values are left to UAA
+type listQuery struct {
+	Filter     Filter
+	SortBy     string
+	SortOrder  string
+	Attributes []string
+	StartIndex int
+	Count      int
+}
+
+func (query listQuery) values() url.Values {
+	values := url.Values{}
+	if !query.Filter.IsZero() {
+		values.Set("filter", query.Filter.String())
+	}
+	if query.SortBy != "" {
+		values.Set("sortBy", query.SortBy)
+	}
+	if query.SortOrder != "" {
+		values.Set("sortOrder", query.SortOrder)
+	}
+	if len(query.Attributes) != 0 {
+		values.Set("attributes", strings.Join(query.Attributes, ","))
+	}
+	if query.StartIndex > 0 {
+		values.Set("startIndex", strconv.Itoa(query.StartIndex))
+	}
+	if query.Count > 0 {
+		values.Set("count", strconv.Itoa(query.Count))
+	}
+	return values
+}
+
+type listResponseJSON struct {
+	Resources    json.RawMessage `json:"resources"`
+	StartIndex   *int            `json:"startIndex"`
+	ItemsPerPage *int            `json:"itemsPerPage"`
+	TotalResults *int            `json:"totalResults"`
+}
+
+// Decodes a SCIM list response, storing its resources in the slice pointed
+// to by resources
+func decodeListResponse(body []byte, resources interface{}) (listResponse, error) {
+	var response listResponse
+
+	var decoded listResponseJSON
+	err := json.Unmarshal(body, &decoded)
+	if err != nil {
+		return response, malformedResponse("%v", err)
+	}
+
+	if len(decoded.Resources) == 0 || string(decoded.Resources) == "null" {
+		return response, malformedResponse("missing resources")
+	}
+
+	if decoded.TotalResults == nil {
+		return response, malformedResponse("missing totalResults")
+	}
+	response.TotalResults = *decoded.TotalResults
+
+	if decoded.StartIndex != nil {
+		response.StartIndex = *decoded.StartIndex
+	}
+
+	if decoded.ItemsPerPage != nil {
+		response.ItemsPerPage = *decoded.ItemsPerPage
+	}
+
+	err = json.Unmarshal(decoded.Resources, resources)
+	if err != nil {
+		return response, malformedResponse("resources: %v", err)
+	}
+
+	return response, nil
+}
+
+// Requests a SCIM list from the given path of the UAA server with an admin
+// client, storing its resources in the slice pointed to by resources
+func (u UAA) listRequest(path string, values url.Values, resources interface{}) (listResponse, error) {
+	if len(values) != 0 {
+		path += "?" + values.Encode()
+	}
+
+	uri, err := url.Parse(u.uaaURL + path)
+	if err != nil {
+		return listResponse{}, err
+	}
+
+	client := u.newAdminClient(uri)
+	code, body, err := client.MakeRequest("GET", uri.RequestURI(), nil)
+	if err != nil {
+		return listResponse{}, err
+	}
+
+	if code > 399 {
+		return listResponse{}, NewFailure(code, body)
+	}
+
+	return decodeListResponse(body, resources)
+}
+
+// Fetches the pages of a SCIM list one after another from startIndex on,
+// until UAA's totalResults is reached or a page comes back empty. fetchPage
+// returns the number of resources in the page it fetched and the total.
+func pageThrough(u UAA, startIndex int, fetchPage func(startIndex int) (int, int, error)) error {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+
+	for {
+		err := u.Context().Err()
+		if err != nil {
+			return err
+		}
+
+		count, totalResults, err := fetchPage(startIndex)
+		if err != nil {
+			return err
+		}
+
+		startIndex += count
+		if count == 0 || startIndex > totalResults {
+			return nil
+		}
+	}
+}
+
+func malformedResponse(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", MalformedResponseError, fmt.Sprintf(format, args...))
+}
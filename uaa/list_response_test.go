@@ -0,0 +1,77 @@
+package uaa_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SCIM list responses", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var response string
+
+	BeforeEach(func() {
+		fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(response))
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	malformed := map[string]string{
+		"not JSON":                 `<html>Bad Gateway</html>`,
+		"not an object":            `[]`,
+		"missing resources":        `{"totalResults": 1}`,
+		"null resources":           `{"resources": null, "totalResults": 1}`,
+		"missing totalResults":     `{"resources": []}`,
+		"a string as totalResults": `{"resources": [], "totalResults": "1"}`,
+		"resources of wrong type":  `{"resources": {"id": "1"}, "totalResults": 1}`,
+		"a malformed user":         `{"resources": [{"id": "1", "userName": 42}], "totalResults": 1}`,
+	}
+
+	for description, body := range malformed {
+		description, body := description, body
+
+		It("returns a MalformedResponseError for "+description, func() {
+			response = body
+
+			users, err := uaa.AllUsers(auth)
+			Expect(errors.Is(err, uaa.MalformedResponseError)).To(BeTrue(), err.Error())
+			Expect(users).To(BeEmpty())
+
+			users, err = uaa.UsersByIDs(auth, "1")
+			Expect(errors.Is(err, uaa.MalformedResponseError)).To(BeTrue(), err.Error())
+			Expect(users).To(BeEmpty())
+
+			users, err = uaa.UsersEmailsByIDs(auth, "1")
+			Expect(errors.Is(err, uaa.MalformedResponseError)).To(BeTrue(), err.Error())
+			Expect(users).To(BeEmpty())
+		})
+	}
+
+	It("names the problem in the error", func() {
+		response = `{"resources": []}`
+
+		_, err := uaa.AllUsers(auth)
+		Expect(err.Error()).To(Equal("Malformed UAA response: missing totalResults"))
+	})
+
+	It("accepts responses without the optional paging fields", func() {
+		response = `{"resources": [{"id": "1", "userName": "admin"}], "totalResults": 1}`
+
+		users, err := uaa.AllUsers(auth)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(users).To(HaveLen(1))
+		Expect(users[0].Username).To(Equal("admin"))
+	})
+})
@@ -0,0 +1,221 @@
+package uaa
+
+import (
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const Redacted = "[REDACTED]"
+
+// Record of a single request attempt made to UAA. Credentials, secrets,
+// codes and tokens in the query, headers and bodies are already redacted.
+type RequestLog struct {
+	Operation     string
+	Method        string
+	Path          string
+	Attempt       int
+	StatusCode    int
+	Duration      time.Duration
+	RequestID     string
+	RequestHeader http.Header
+	RequestBody   string
+	ResponseBody  string
+	Err           error
+}
+
+// Receives a RequestLog for every request attempt a Client makes
+type Logger interface {
+	LogRequest(RequestLog)
+}
+
+// Adapts an ordinary function to the Logger interface
+type LoggerFunc func(RequestLog)
+
+func (f LoggerFunc) LogRequest(entry RequestLog) {
+	f(entry)
+}
+
+type standardLogger struct {
+	logger *log.Logger
+}
+
+// Returns a Logger that writes a line per request to the given log.Logger,
+// or to the standard logger when it is nil. Bodies are not written.
+func NewStandardLogger(logger *log.Logger) Logger {
+	return standardLogger{logger: logger}
+}
+
+func (logger standardLogger) LogRequest(entry RequestLog) {
+	format := "UAA %s %s %s attempt=%d status=%d duration=%s request_id=%q"
+	args := []interface{}{entry.Operation, entry.Method, entry.Path, entry.Attempt, entry.StatusCode, entry.Duration, entry.RequestID}
+	if entry.Err != nil {
+		format += " error=%q"
+		args = append(args, entry.Err.Error())
+	}
+
+	if logger.logger == nil {
+		log.Printf(format, args...)
+		return
+	}
+	logger.logger.Printf(format, args...)
+}
+
+func newRequestLog(operation string, request *http.Request, payload []byte, attempt int, code int, header http.Header, body []byte, duration time.Duration, err error) RequestLog {
+	return RequestLog{
+		Operation:     operation,
+		Method:        request.Method,
+		Path:          redactRequestURI(request.URL),
+		Attempt:       attempt,
+		StatusCode:    code,
+		Duration:      duration,
+		RequestID:     requestID(header),
+		RequestHeader: RedactHeader(request.Header),
+		RequestBody:   RedactBody(payload, request.Header.Get("Content-Type")),
+		ResponseBody:  RedactBody(body, header.Get("Content-Type")),
+		Err:           err,
+	}
+}
+
+// UAA echoes the request ID set by the gorouter, when deployed behind one
+func requestID(header http.Header) string {
+	for _, name := range []string{"X-Request-Id", "X-Vcap-Request-Id"} {
+		if id := header.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// Returns a copy of the header with credentials redacted
+func RedactHeader(header http.Header) http.Header {
+	redacted := http.Header{}
+	for name, values := range header {
+		if isSensitiveHeader(name) {
+			values = []string{Redacted}
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
+
+// Redacts sensitive values in a body. Bodies are treated as form encoded
+// only when the content type says so, otherwise as JSON; bodies that are not
+// valid JSON are returned unchanged.
+func RedactBody(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if isFormContentType(contentType) {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return Redacted
+		}
+		return redactValues(values).Encode()
+	}
+
+	var parsed interface{}
+	if json.Unmarshal(body, &parsed) == nil {
+		redacted, err := json.Marshal(redactJSON(parsed))
+		if err == nil {
+			return string(redacted)
+		}
+	}
+
+	return string(body)
+}
+
+// Returns the path and query of the URL, with sensitive query values redacted
+func redactRequestURI(requestURL *url.URL) string {
+	if requestURL.RawQuery == "" {
+		return requestURL.RequestURI()
+	}
+
+	redacted := *requestURL
+	values, err := url.ParseQuery(requestURL.RawQuery)
+	if err != nil {
+		redacted.RawQuery = Redacted
+	} else {
+		redacted.RawQuery = redactValues(values).Encode()
+	}
+	return redacted.RequestURI()
+}
+
+func redactValues(values url.Values) url.Values {
+	for key := range values {
+		if isSensitiveKey(key) {
+			values[key] = []string{Redacted}
+		}
+	}
+	return values
+}
+
+func isFormContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
+
+func redactJSON(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if isSensitiveKey(key) {
+				value[key] = Redacted
+			} else {
+				value[key] = redactJSON(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = redactJSON(child)
+		}
+	case string:
+		// Identity provider configs are JSON objects encoded as strings
+		if !strings.HasPrefix(value, "{") {
+			return value
+		}
+		var parsed map[string]interface{}
+		if json.Unmarshal([]byte(value), &parsed) != nil {
+			return value
+		}
+		redacted, err := json.Marshal(redactJSON(parsed))
+		if err == nil {
+			return string(redacted)
+		}
+	}
+	return value
+}
+
+func isSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	return name == "authorization" || name == "cookie" || name == "set-cookie"
+}
+
+var sensitiveKeys = map[string]bool{
+	"code":          true,
+	"passcode":      true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"assertion":     true,
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if sensitiveKeys[key] {
+		return true
+	}
+
+	for _, fragment := range []string{"password", "secret", "privatekey", "signingkey", "passphrase"} {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
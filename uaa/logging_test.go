@@ -0,0 +1,163 @@
+package uaa_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logging", func() {
+	var server *httptest.Server
+	var entries []uaa.RequestLog
+
+	BeforeEach(func() {
+		entries = []uaa.RequestLog{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Vcap-Request-Id", "the-request-id")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+                "access_token": "access-token",
+                "refresh_token": "refresh-token",
+                "token_type": "bearer"
+            }`))
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("records every request a UAA makes, without credentials or tokens", func() {
+		auth := uaa.NewUAA("http://login.example.com", server.URL, "the-client-id", "the-client-secret", "")
+		auth.Logger = uaa.LoggerFunc(func(entry uaa.RequestLog) {
+			entries = append(entries, entry)
+		})
+
+		_, err := uaa.Exchange(auth, "the-auth-code")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(entries).To(HaveLen(1))
+		entry := entries[0]
+		Expect(entry.Operation).To(Equal("Exchange"))
+		Expect(entry.Method).To(Equal("POST"))
+		Expect(entry.Path).To(Equal("/oauth/token"))
+		Expect(entry.Attempt).To(Equal(1))
+		Expect(entry.StatusCode).To(Equal(http.StatusOK))
+		Expect(entry.Duration).To(BeNumerically(">", 0))
+		Expect(entry.RequestID).To(Equal("the-request-id"))
+		Expect(entry.RequestHeader.Get("Authorization")).To(Equal(uaa.Redacted))
+
+		form, err := url.ParseQuery(entry.RequestBody)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(form.Get("grant_type")).To(Equal("authorization_code"))
+		Expect(form.Get("code")).To(Equal(uaa.Redacted))
+
+		Expect(entry.ResponseBody).NotTo(ContainSubstring("access-token"))
+		Expect(entry.ResponseBody).NotTo(ContainSubstring("refresh-token"))
+		Expect(entry.ResponseBody).To(ContainSubstring(`"token_type":"bearer"`))
+	})
+
+	It("records failed attempts", func() {
+		server.Close()
+		client := uaa.NewClient(server.URL, false).WithLogger(uaa.LoggerFunc(func(entry uaa.RequestLog) {
+			entries = append(entries, entry)
+		}))
+
+		_, _, err := client.MakeRequest("GET", "/Users?startIndex=4", nil)
+
+		Expect(err).To(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Path).To(Equal("/Users?startIndex=4"))
+		Expect(entries[0].Err).To(Equal(err))
+	})
+
+	It("redacts sensitive query parameters in the path", func() {
+		client := uaa.NewClient(server.URL, false).WithLogger(uaa.LoggerFunc(func(entry uaa.RequestLog) {
+			entries = append(entries, entry)
+		}))
+
+		client.MakeRequest("GET", "/oauth/authorize?client_id=app&code=the-code&client_secret=shh", nil)
+
+		Expect(entries).To(HaveLen(1))
+		path, err := url.Parse(entries[0].Path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(path.Path).To(Equal("/oauth/authorize"))
+		Expect(path.Query().Get("client_id")).To(Equal("app"))
+		Expect(path.Query().Get("code")).To(Equal(uaa.Redacted))
+		Expect(path.Query().Get("client_secret")).To(Equal(uaa.Redacted))
+		Expect(entries[0].Path).NotTo(ContainSubstring("the-code"))
+	})
+
+	Describe("RedactBody", func() {
+		It("redacts secrets in form bodies", func() {
+			body := uaa.RedactBody([]byte("grant_type=password&username=admin&password=hunter2&client_secret=shh"), "application/x-www-form-urlencoded")
+
+			form, err := url.ParseQuery(body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(form.Get("username")).To(Equal("admin"))
+			Expect(form.Get("password")).To(Equal(uaa.Redacted))
+			Expect(form.Get("client_secret")).To(Equal(uaa.Redacted))
+		})
+
+		It("redacts secrets nested anywhere in JSON bodies", func() {
+			body := uaa.RedactBody([]byte(`{"client_id":"app","client_secret":"shh","config":{"bindPassword":"hunter2"},"users":[{"password":"pw","userName":"joe"}]}`), "application/json")
+
+			Expect(body).To(MatchJSON(`{"client_id":"app","client_secret":"[REDACTED]","config":{"bindPassword":"[REDACTED]"},"users":[{"password":"[REDACTED]","userName":"joe"}]}`))
+		})
+
+		It("redacts secrets in JSON objects encoded as strings", func() {
+			body := uaa.RedactBody([]byte(`{"type":"ldap","config":"{\"baseUrl\":\"ldap://localhost\",\"bindPassword\":\"hunter2\"}"}`), "application/json;charset=UTF-8")
+
+			var redacted map[string]string
+			Expect(json.Unmarshal([]byte(body), &redacted)).To(Succeed())
+			Expect(redacted["config"]).To(MatchJSON(`{"baseUrl":"ldap://localhost","bindPassword":"[REDACTED]"}`))
+		})
+
+		It("leaves other bodies alone", func() {
+			Expect(uaa.RedactBody([]byte("<html>Bad Gateway</html>"), "text/html")).To(Equal("<html>Bad Gateway</html>"))
+			Expect(uaa.RedactBody(nil, "application/json")).To(Equal(""))
+		})
+
+		It("does not treat bodies as forms unless their content type says so", func() {
+			Expect(uaa.RedactBody([]byte("password=hunter2"), "text/plain")).To(Equal("password=hunter2"))
+			Expect(uaa.RedactBody([]byte(`{"filter":"a=b&c=d"`), "application/json")).To(Equal(`{"filter":"a=b&c=d"`))
+		})
+	})
+
+	Describe("RedactHeader", func() {
+		It("redacts credentials without modifying the original header", func() {
+			header := http.Header{}
+			header.Set("Authorization", "Bearer my-special-token")
+			header.Set("Content-Type", "application/json")
+
+			redacted := uaa.RedactHeader(header)
+
+			Expect(redacted.Get("Authorization")).To(Equal(uaa.Redacted))
+			Expect(redacted.Get("Content-Type")).To(Equal("application/json"))
+			Expect(header.Get("Authorization")).To(Equal("Bearer my-special-token"))
+		})
+	})
+
+	Describe("NewStandardLogger", func() {
+		It("writes a line per request", func() {
+			buffer := bytes.NewBuffer([]byte{})
+			client := uaa.NewClient(server.URL, false).WithLogger(uaa.NewStandardLogger(log.New(buffer, "", 0)))
+
+			client.MakeRequest("GET", "/token_key", nil)
+
+			line := buffer.String()
+			Expect(strings.Count(line, "\n")).To(Equal(1))
+			Expect(line).To(HavePrefix(`UAA Request GET /token_key attempt=1 status=200 duration=`))
+			Expect(line).To(ContainSubstring(`request_id="the-request-id"`))
+		})
+	})
+})
@@ -0,0 +1,155 @@
+package uaa
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Receives an observation for every request a Client makes, after any
+// retries. Operation is the UAA operation, like "Exchange" or "AllUsers",
+// and statusClass is one of "2xx" through "5xx", or "error" when no
+// response was received.
+type Metrics interface {
+	ObserveRequest(operation, statusClass string, duration time.Duration)
+}
+
+// Returns the class of a response status, as reported to Metrics
+func StatusClass(code int, err error) string {
+	if err != nil || code < 100 {
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", code/100)
+}
+
+// Upper bounds of the latency histogram buckets used by ExpvarMetrics
+var DefaultLatencyBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Metrics exported through the expvar package, and so readable from
+// /debug/vars. Every operation is published as
+//
+//	{"requests": {"2xx": 12, "4xx": 1}, "latency": {"count": 13, "sum_ms": 84.2, "buckets": {"5ms": 3, ..., "+Inf": 13}}}
+//
+// where the buckets are cumulative.
+type ExpvarMetrics struct {
+	operations *expvar.Map
+	buckets    []time.Duration
+	mutex      sync.Mutex
+}
+
+// Keeps concurrent NewExpvarMetrics calls from publishing a name twice
+var expvarMutex sync.Mutex
+
+// Publishes metrics under the given expvar name, reusing the variable when
+// it was published before. Names published as anything but an expvar.Map
+// are rejected with an error.
+func NewExpvarMetrics(name string) (*ExpvarMetrics, error) {
+	expvarMutex.Lock()
+	defer expvarMutex.Unlock()
+
+	var operations *expvar.Map
+	switch published := expvar.Get(name).(type) {
+	case nil:
+		operations = expvar.NewMap(name)
+	case *expvar.Map:
+		operations = published
+	default:
+		return nil, fmt.Errorf("expvar %q is already published as a %T", name, published)
+	}
+
+	return &ExpvarMetrics{
+		operations: operations,
+		buckets:    DefaultLatencyBuckets,
+	}, nil
+}
+
+func (metrics *ExpvarMetrics) ObserveRequest(operation, statusClass string, duration time.Duration) {
+	requests, latency := metrics.operation(operation)
+	requests.Add(statusClass, 1)
+	latency.observe(duration)
+}
+
+// Returns the variables published for the given operation
+func (metrics *ExpvarMetrics) Operation(operation string) *expvar.Map {
+	metrics.operation(operation)
+	return metrics.operations.Get(operation).(*expvar.Map)
+}
+
+func (metrics *ExpvarMetrics) operation(operation string) (*expvar.Map, *histogram) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	variables, ok := metrics.operations.Get(operation).(*expvar.Map)
+	if !ok {
+		variables = new(expvar.Map).Init()
+		variables.Set("requests", new(expvar.Map).Init())
+		variables.Set("latency", newHistogram(metrics.buckets))
+		metrics.operations.Set(operation, variables)
+	}
+
+	return variables.Get("requests").(*expvar.Map), variables.Get("latency").(*histogram)
+}
+
+// Latency histogram implementing expvar.Var
+type histogram struct {
+	mutex   sync.Mutex
+	buckets []time.Duration
+	counts  []int64
+	count   int64
+	sum     time.Duration
+}
+
+func newHistogram(buckets []time.Duration) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]int64, len(buckets)),
+	}
+}
+
+func (h *histogram) observe(duration time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.count++
+	h.sum += duration
+	for i, bound := range h.buckets {
+		if duration <= bound {
+			h.counts[i]++
+		}
+	}
+}
+
+func (h *histogram) String() string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	buckets := map[string]int64{"+Inf": h.count}
+	for i, bound := range h.buckets {
+		buckets[bound.String()] = h.counts[i]
+	}
+
+	output, err := json.Marshal(map[string]interface{}{
+		"count":   h.count,
+		"sum_ms":  json.Number(strconv.FormatFloat(float64(h.sum)/float64(time.Millisecond), 'f', -1, 64)),
+		"buckets": buckets,
+	})
+	if err != nil {
+		return "{}"
+	}
+	return string(output)
+}
@@ -0,0 +1,181 @@
+package uaa_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type observation struct {
+	operation   string
+	statusClass string
+	duration    time.Duration
+}
+
+type fakeMetrics struct {
+	observations []observation
+}
+
+func (metrics *fakeMetrics) ObserveRequest(operation, statusClass string, duration time.Duration) {
+	metrics.observations = append(metrics.observations, observation{operation, statusClass, duration})
+}
+
+// Numbers the expvar names published by the specs
+var expvarSpecs int
+
+var _ = Describe("Metrics", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+
+	BeforeEach(func() {
+		fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			switch {
+			case req.URL.Path == "/Users":
+				responseObj := map[string]interface{}{
+					"resources":    usersFirstPage,
+					"startIndex":   1,
+					"itemsPerPage": 3,
+					"totalResults": 4,
+				}
+				if strings.Contains(req.URL.RawQuery, "startIndex=4") {
+					responseObj["resources"] = usersSecondPage
+					responseObj["startIndex"] = 4
+				}
+				if req.URL.Query().Get("filter") != "" {
+					responseObj["resources"] = usersFirstPage[:1]
+					responseObj["totalResults"] = 1
+				}
+				response, err := json.Marshal(responseObj)
+				if err != nil {
+					panic(err)
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write(response)
+			case req.URL.Path == "/oauth/token":
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_grant"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	It("observes every request under the name of its operation", func() {
+		metrics := &fakeMetrics{}
+		auth.Metrics = metrics
+
+		uaa.AllUsers(auth)
+		uaa.UsersByIDs(auth, "some-id")
+		uaa.Exchange(auth, "1234")
+		uaa.UserByID(auth, "some-id")
+
+		Expect(metrics.observations).To(HaveLen(5))
+		Expect(metrics.observations[0].operation).To(Equal("AllUsers"))
+		Expect(metrics.observations[0].statusClass).To(Equal("2xx"))
+		Expect(metrics.observations[0].duration).To(BeNumerically(">", 0))
+		Expect(metrics.observations[1].operation).To(Equal("AllUsers"))
+		Expect(metrics.observations[2].operation).To(Equal("UsersByIDs"))
+		Expect(metrics.observations[3].operation).To(Equal("Exchange"))
+		Expect(metrics.observations[3].statusClass).To(Equal("4xx"))
+		Expect(metrics.observations[4].operation).To(Equal("UserByID"))
+		Expect(metrics.observations[4].statusClass).To(Equal("4xx"))
+	})
+
+	It("reports requests that received no response", func() {
+		metrics := &fakeMetrics{}
+		fakeUAAServer.Close()
+
+		client := uaa.NewClient(fakeUAAServer.URL, false).WithMetrics(metrics).WithOperation("GetTokenKey")
+		client.MakeRequest("GET", "/token_key", nil)
+
+		Expect(metrics.observations).To(HaveLen(1))
+		Expect(metrics.observations[0].operation).To(Equal("GetTokenKey"))
+		Expect(metrics.observations[0].statusClass).To(Equal("error"))
+	})
+
+	Describe("StatusClass", func() {
+		It("groups statuses by their first digit", func() {
+			Expect(uaa.StatusClass(200, nil)).To(Equal("2xx"))
+			Expect(uaa.StatusClass(302, nil)).To(Equal("3xx"))
+			Expect(uaa.StatusClass(404, nil)).To(Equal("4xx"))
+			Expect(uaa.StatusClass(503, nil)).To(Equal("5xx"))
+			Expect(uaa.StatusClass(0, http.ErrHandlerTimeout)).To(Equal("error"))
+		})
+	})
+
+	Describe("ExpvarMetrics", func() {
+		var name string
+
+		BeforeEach(func() {
+			// expvar variables live as long as the process, so every spec
+			// publishes its own
+			expvarSpecs++
+			name = fmt.Sprintf("uaa-metrics-test-%d", expvarSpecs)
+		})
+
+		It("publishes request counts and latency histograms per operation", func() {
+			metrics, err := uaa.NewExpvarMetrics(name)
+			Expect(err).NotTo(HaveOccurred())
+			auth.Metrics = metrics
+
+			uaa.AllUsers(auth)
+			uaa.Exchange(auth, "1234")
+
+			requests := metrics.Operation("AllUsers").Get("requests").(*expvar.Map)
+			Expect(requests.Get("2xx").String()).To(Equal("2"))
+			requests = metrics.Operation("Exchange").Get("requests").(*expvar.Map)
+			Expect(requests.Get("4xx").String()).To(Equal("1"))
+
+			var published map[string]struct {
+				Requests map[string]int `json:"requests"`
+				Latency  struct {
+					Count   int            `json:"count"`
+					SumMS   float64        `json:"sum_ms"`
+					Buckets map[string]int `json:"buckets"`
+				} `json:"latency"`
+			}
+			err = json.Unmarshal([]byte(expvar.Get(name).String()), &published)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(published["AllUsers"].Requests).To(Equal(map[string]int{"2xx": 2}))
+			Expect(published["AllUsers"].Latency.Count).To(Equal(2))
+			Expect(published["AllUsers"].Latency.SumMS).To(BeNumerically(">", 0))
+			Expect(published["AllUsers"].Latency.Buckets["+Inf"]).To(Equal(2))
+			Expect(published["AllUsers"].Latency.Buckets["10s"]).To(Equal(2))
+		})
+
+		It("reuses a variable that was already published", func() {
+			first, err := uaa.NewExpvarMetrics(name)
+			Expect(err).NotTo(HaveOccurred())
+			first.ObserveRequest("UserByID", "2xx", time.Millisecond)
+
+			second, err := uaa.NewExpvarMetrics(name)
+			Expect(err).NotTo(HaveOccurred())
+			second.ObserveRequest("UserByID", "2xx", time.Millisecond)
+
+			requests := second.Operation("UserByID").Get("requests").(*expvar.Map)
+			Expect(requests.Get("2xx").String()).To(Equal("2"))
+		})
+
+		It("returns an error when the name is published as another kind of variable", func() {
+			expvar.NewString(name)
+
+			_, err := uaa.NewExpvarMetrics(name)
+			Expect(err).To(MatchError(ContainSubstring(name)))
+		})
+	})
+})
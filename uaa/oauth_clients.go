@@ -0,0 +1,223 @@
+package uaa
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+type OAuthClientsInterface interface {
+	CreateOAuthClient(OAuthClient) (OAuthClient, error)
+	OAuthClientByID(string) (OAuthClient, error)
+	OAuthClients(OAuthClientsQuery) (OAuthClientsPage, error)
+	UpdateOAuthClient(OAuthClient) (OAuthClient, error)
+	DeleteOAuthClient(string) error
+	ChangeOAuthClientSecret(string, string, string) error
+	OAuthClientMetadataByID(string) (OAuthClientMetadata, error)
+	UpdateOAuthClientMetadata(OAuthClientMetadata) (OAuthClientMetadata, error)
+}
+
+// Client registration managed through /oauth/clients. ClientSecret is only
+// sent when creating clients, use ChangeOAuthClientSecret to change it. Token
+// validities are in seconds, zero uses the zone defaults.
+type OAuthClient struct {
+	ClientID             string      `json:"client_id"`
+	ClientSecret         string      `json:"client_secret,omitempty"`
+	Name                 string      `json:"name,omitempty"`
+	Scope                []string    `json:"scope,omitempty"`
+	ResourceIDs          []string    `json:"resource_ids,omitempty"`
+	AuthorizedGrantTypes []string    `json:"authorized_grant_types"`
+	RedirectURI          []string    `json:"redirect_uri,omitempty"`
+	Authorities          []string    `json:"authorities,omitempty"`
+	AutoApprove          AutoApprove `json:"autoapprove"`
+	AccessTokenValidity  int         `json:"access_token_validity,omitempty"`
+	RefreshTokenValidity int         `json:"refresh_token_validity,omitempty"`
+	AllowedProviders     []string    `json:"allowedproviders,omitempty"`
+	RequiredUserGroups   []string    `json:"required_user_groups,omitempty"`
+	TokenSalt            string      `json:"token_salt,omitempty"`
+	LastModified         int64       `json:"lastModified,omitempty"`
+}
+
+// Scopes users are not asked to approve. UAA represents approving every scope
+// as true, and some scopes as a list of them.
+type AutoApprove struct {
+	All    bool
+	Scopes []string
+}
+
+func (autoApprove AutoApprove) MarshalJSON() ([]byte, error) {
+	if autoApprove.All {
+		return []byte("true"), nil
+	}
+
+	if autoApprove.Scopes == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal(autoApprove.Scopes)
+}
+
+func (autoApprove *AutoApprove) UnmarshalJSON(data []byte) error {
+	*autoApprove = AutoApprove{}
+
+	var value interface{}
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case nil:
+	case bool:
+		autoApprove.All = value
+	case string:
+		autoApprove.All = value == "true"
+	case []interface{}:
+		for _, scope := range value {
+			scope, ok := scope.(string)
+			if !ok {
+				return fmt.Errorf("invalid autoapprove scope %v", scope)
+			}
+			if scope == "true" {
+				autoApprove.All = true
+				continue
+			}
+			autoApprove.Scopes = append(autoApprove.Scopes, scope)
+		}
+	default:
+		return fmt.Errorf("invalid autoapprove value %s", data)
+	}
+
+	return nil
+}
+
+// Filter is a SCIM filter, like Sw("client_id", "app-"). StartIndex is
+// 1-based, and zero values are left to UAA.
+type OAuthClientsQuery struct {
+	Filter     Filter
+	SortBy     string
+	SortOrder  string
+	StartIndex int
+	Count      int
+}
+
+func (query OAuthClientsQuery) values() url.Values {
+	return listQuery{
+		Filter:     query.Filter,
+		SortBy:     query.SortBy,
+		SortOrder:  query.SortOrder,
+		StartIndex: query.StartIndex,
+		Count:      query.Count,
+	}.values()
+}
+
+type OAuthClientsPage struct {
+	Resources    []OAuthClient `json:"resources"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	TotalResults int           `json:"totalResults"`
+}
+
+type OAuthClientMetadata struct {
+	ClientID       string `json:"clientId"`
+	ClientName     string `json:"clientName,omitempty"`
+	ShowOnHomePage bool   `json:"showOnHomePage"`
+	AppLaunchURL   string `json:"appLaunchUrl,omitempty"`
+	AppIcon        string `json:"appIcon,omitempty"`
+	CreatedBy      string `json:"createdBy,omitempty"`
+}
+
+type clientSecretChange struct {
+	ClientID   string `json:"clientId"`
+	OldSecret  string `json:"oldSecret,omitempty"`
+	Secret     string `json:"secret,omitempty"`
+	ChangeMode string `json:"changeMode,omitempty"`
+}
+
+func CreateOAuthClient(u UAA, client OAuthClient) (_ OAuthClient, err error) {
+	u, span := u.withOperation("CreateOAuthClient")
+	defer span.end(&err)
+
+	var created OAuthClient
+	err = u.adminRequest("POST", "/oauth/clients", nil, client, &created)
+	return created, err
+}
+
+func OAuthClientByID(u UAA, id string) (_ OAuthClient, err error) {
+	u, span := u.withOperation("OAuthClientByID")
+	defer span.end(&err)
+
+	var client OAuthClient
+	err = u.adminRequest("GET", "/oauth/clients/"+url.PathEscape(id), nil, nil, &client)
+	return client, err
+}
+
+// Returns a single page of clients, use StartIndex to request the next ones
+func OAuthClients(u UAA, query OAuthClientsQuery) (_ OAuthClientsPage, err error) {
+	u, span := u.withOperation("OAuthClients")
+	defer span.end(&err)
+
+	clients := []OAuthClient{}
+	response, err := u.listRequest("/oauth/clients", query.values(), &clients)
+	if err != nil {
+		return OAuthClientsPage{Resources: []OAuthClient{}}, err
+	}
+
+	return OAuthClientsPage{
+		Resources:    clients,
+		StartIndex:   response.StartIndex,
+		ItemsPerPage: response.ItemsPerPage,
+		TotalResults: response.TotalResults,
+	}, nil
+}
+
+// Updates everything but the secret of the client
+func UpdateOAuthClient(u UAA, client OAuthClient) (_ OAuthClient, err error) {
+	u, span := u.withOperation("UpdateOAuthClient")
+	defer span.end(&err)
+
+	client.ClientSecret = ""
+
+	var updated OAuthClient
+	err = u.adminRequest("PUT", "/oauth/clients/"+url.PathEscape(client.ClientID), nil, client, &updated)
+	return updated, err
+}
+
+func DeleteOAuthClient(u UAA, id string) (err error) {
+	u, span := u.withOperation("DeleteOAuthClient")
+	defer span.end(&err)
+
+	return u.adminRequest("DELETE", "/oauth/clients/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// Replaces the secret of the client. The old secret is required when the
+// access token belongs to the client itself rather than to an admin.
+func ChangeOAuthClientSecret(u UAA, id, oldSecret, newSecret string) (err error) {
+	u, span := u.withOperation("ChangeOAuthClientSecret")
+	defer span.end(&err)
+
+	change := clientSecretChange{
+		ClientID:  id,
+		OldSecret: oldSecret,
+		Secret:    newSecret,
+	}
+	return u.adminRequest("PUT", "/oauth/clients/"+url.PathEscape(id)+"/secret", nil, change, nil)
+}
+
+func OAuthClientMetadataByID(u UAA, id string) (_ OAuthClientMetadata, err error) {
+	u, span := u.withOperation("OAuthClientMetadataByID")
+	defer span.end(&err)
+
+	var metadata OAuthClientMetadata
+	err = u.adminRequest("GET", "/oauth/clients/"+url.PathEscape(id)+"/meta", nil, nil, &metadata)
+	return metadata, err
+}
+
+func UpdateOAuthClientMetadata(u UAA, metadata OAuthClientMetadata) (_ OAuthClientMetadata, err error) {
+	u, span := u.withOperation("UpdateOAuthClientMetadata")
+	defer span.end(&err)
+
+	var updated OAuthClientMetadata
+	err = u.adminRequest("PUT", "/oauth/clients/"+url.PathEscape(metadata.ClientID)+"/meta", nil, metadata, &updated)
+	return updated, err
+}
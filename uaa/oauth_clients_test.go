@@ -0,0 +1,224 @@
+package uaa_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const oauthClientJSON = `{
+  "client_id": "my-app",
+  "name": "My App",
+  "scope": ["openid", "cloud_controller.read"],
+  "resource_ids": ["none"],
+  "authorized_grant_types": ["authorization_code", "refresh_token"],
+  "redirect_uri": ["https://my-app.example.com/**"],
+  "authorities": ["uaa.none"],
+  "autoapprove": ["openid"],
+  "access_token_validity": 600,
+  "refresh_token_validity": 86400,
+  "allowedproviders": ["uaa", "ldap"],
+  "lastModified": 1513708000000
+}`
+
+var _ = Describe("OAuthClients", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var requests []*http.Request
+	var requestBodies []string
+
+	BeforeEach(func() {
+		requests = []*http.Request{}
+		requestBodies = []string{}
+		fakeUAAServer = httptest.NewServer(recordRequests(&requests, &requestBodies, func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "Bearer my-special-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			switch {
+			case req.URL.Path == "/oauth/clients" && req.Method == "POST":
+				var client map[string]interface{}
+				json.NewDecoder(req.Body).Decode(&client)
+				if client["client_id"] == "taken" {
+					w.WriteHeader(http.StatusConflict)
+					w.Write([]byte(`{"error":"invalid_client","error_description":"Client already exists: taken"}`))
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(oauthClientJSON))
+			case req.URL.Path == "/oauth/clients" && req.Method == "GET":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"resources":[` + oauthClientJSON + `],"startIndex":11,"itemsPerPage":1,"totalResults":12,"schemas":["http://cloudfoundry.org/schema/scim/oauth-clients-1.0"]}`))
+			case req.URL.Path == "/oauth/clients/my-app":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(oauthClientJSON))
+			case req.URL.Path == "/oauth/clients/my-app/secret":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"status":"ok","message":"secret updated"}`))
+			case req.URL.Path == "/oauth/clients/my-app/meta":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"clientId":"my-app","clientName":"My App","showOnHomePage":true,"appLaunchUrl":"https://my-app.example.com","createdBy":"admin-id"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":"not_found","error_description":"No client with requested id: missing"}`))
+			}
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	It("creates clients", func() {
+		client, err := uaa.CreateOAuthClient(auth, uaa.OAuthClient{
+			ClientID:             "my-app",
+			ClientSecret:         "my-app-secret",
+			Scope:                []string{"openid"},
+			AuthorizedGrantTypes: []string{"client_credentials"},
+			Authorities:          []string{"scim.read"},
+			AutoApprove:          uaa.AutoApprove{All: true},
+			AccessTokenValidity:  600,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests[0].Method).To(Equal("POST"))
+		Expect(requestBodies[0]).To(MatchJSON(`{
+            "client_id": "my-app",
+            "client_secret": "my-app-secret",
+            "scope": ["openid"],
+            "authorized_grant_types": ["client_credentials"],
+            "authorities": ["scim.read"],
+            "autoapprove": true,
+            "access_token_validity": 600
+        }`))
+
+		Expect(client.Name).To(Equal("My App"))
+		Expect(client.RedirectURI).To(Equal([]string{"https://my-app.example.com/**"}))
+		Expect(client.AutoApprove).To(Equal(uaa.AutoApprove{Scopes: []string{"openid"}}))
+		Expect(client.RefreshTokenValidity).To(Equal(86400))
+		Expect(client.AllowedProviders).To(Equal([]string{"uaa", "ldap"}))
+		Expect(client.LastModified).To(Equal(int64(1513708000000)))
+	})
+
+	It("reports clients that already exist as conflicts", func() {
+		_, err := uaa.CreateOAuthClient(auth, uaa.OAuthClient{ClientID: "taken"})
+
+		Expect(uaa.IsConflict(err)).To(BeTrue())
+		Expect(err.(uaa.Failure).ErrorDescription()).To(Equal("Client already exists: taken"))
+	})
+
+	It("returns clients by ID", func() {
+		client, err := uaa.OAuthClientByID(auth, "my-app")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Scope).To(Equal([]string{"openid", "cloud_controller.read"}))
+
+		_, err = uaa.OAuthClientByID(auth, "missing")
+		Expect(uaa.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("lists pages of clients with filters", func() {
+		page, err := uaa.OAuthClients(auth, uaa.OAuthClientsQuery{
+			Filter:     uaa.Sw("client_id", "my-"),
+			SortBy:     "client_id",
+			SortOrder:  "descending",
+			StartIndex: 11,
+			Count:      1,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		query := requests[0].URL.Query()
+		Expect(query.Get("filter")).To(Equal(`client_id sw "my-"`))
+		Expect(query.Get("sortBy")).To(Equal("client_id"))
+		Expect(query.Get("sortOrder")).To(Equal("descending"))
+		Expect(query.Get("startIndex")).To(Equal("11"))
+		Expect(query.Get("count")).To(Equal("1"))
+
+		Expect(page.StartIndex).To(Equal(11))
+		Expect(page.ItemsPerPage).To(Equal(1))
+		Expect(page.TotalResults).To(Equal(12))
+		Expect(page.Resources).To(HaveLen(1))
+		Expect(page.Resources[0].ClientID).To(Equal("my-app"))
+	})
+
+	It("leaves unset list options to UAA", func() {
+		_, err := uaa.OAuthClients(auth, uaa.OAuthClientsQuery{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests[0].URL.RawQuery).To(Equal(""))
+	})
+
+	It("updates clients without sending their secret", func() {
+		_, err := uaa.UpdateOAuthClient(auth, uaa.OAuthClient{ClientID: "my-app", ClientSecret: "ignored", AuthorizedGrantTypes: []string{"implicit"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests[0].Method).To(Equal("PUT"))
+		Expect(requests[0].URL.Path).To(Equal("/oauth/clients/my-app"))
+		Expect(requestBodies[0]).To(MatchJSON(`{"client_id":"my-app","authorized_grant_types":["implicit"],"autoapprove":[]}`))
+	})
+
+	It("deletes clients", func() {
+		err := uaa.DeleteOAuthClient(auth, "my-app")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests[0].Method).To(Equal("DELETE"))
+		Expect(requests[0].URL.Path).To(Equal("/oauth/clients/my-app"))
+	})
+
+	It("changes client secrets", func() {
+		err := uaa.ChangeOAuthClientSecret(auth, "my-app", "old-secret", "new-secret")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests[0].Method).To(Equal("PUT"))
+		Expect(requestBodies[0]).To(MatchJSON(`{"clientId":"my-app","oldSecret":"old-secret","secret":"new-secret"}`))
+	})
+
+	It("reads and updates client metadata", func() {
+		metadata, err := uaa.OAuthClientMetadataByID(auth, "my-app")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metadata).To(Equal(uaa.OAuthClientMetadata{
+			ClientID:       "my-app",
+			ClientName:     "My App",
+			ShowOnHomePage: true,
+			AppLaunchURL:   "https://my-app.example.com",
+			CreatedBy:      "admin-id",
+		}))
+
+		metadata.AppLaunchURL = "https://new.example.com"
+		_, err = uaa.UpdateOAuthClientMetadata(auth, metadata)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests[1].Method).To(Equal("PUT"))
+		Expect(requests[1].URL.Path).To(Equal("/oauth/clients/my-app/meta"))
+		Expect(requestBodies[1]).To(ContainSubstring(`"appLaunchUrl":"https://new.example.com"`))
+	})
+
+	Describe("AutoApprove", func() {
+		It("decodes every representation UAA uses", func() {
+			for data, expected := range map[string]uaa.AutoApprove{
+				`true`:               {All: true},
+				`false`:              {},
+				`"true"`:             {All: true},
+				`null`:               {},
+				`["true"]`:           {All: true},
+				`["openid","email"]`: {Scopes: []string{"openid", "email"}},
+			} {
+				var autoApprove uaa.AutoApprove
+				Expect(json.Unmarshal([]byte(data), &autoApprove)).To(Succeed())
+				Expect(autoApprove).To(Equal(expected), data)
+			}
+		})
+
+		It("rejects other values", func() {
+			var autoApprove uaa.AutoApprove
+			Expect(json.Unmarshal([]byte(`12`), &autoApprove)).NotTo(Succeed())
+			Expect(json.Unmarshal([]byte(`[12]`), &autoApprove)).NotTo(Succeed())
+		})
+	})
+})
@@ -0,0 +1,517 @@
+package uaa
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type ReconcileGroupsInterface interface {
+	PlanGroups([]DesiredGroup) (GroupsPlan, error)
+	ApplyGroupsPlan(GroupsPlan, ApplyGroupsOptions) (GroupsApplyReport, error)
+}
+
+// Number of changes ApplyGroupsPlan makes at once unless told otherwise
+const DefaultApplyGroupsConcurrency = 4
+
+// Number of display names looked up per request while planning
+const groupsPerQuery = 50
+
+// Group as it should be in UAA. Users are matched by username, within Origin
+// when it is set, and Groups are the display names of the nested groups. The
+// description is only set on groups the plan creates.
+type DesiredGroup struct {
+	DisplayName string
+	Description string
+	Users       []DesiredUser
+	Groups      []string
+}
+
+type DesiredUser struct {
+	Username string
+	Origin   string
+}
+
+type GroupChangeType string
+
+const (
+	CreateGroupChange  GroupChangeType = "create"
+	AddMemberChange    GroupChangeType = "add"
+	RemoveMemberChange GroupChangeType = "remove"
+)
+
+// Change to one group. GroupID is empty for groups the plan creates, and so
+// is Member.Value for nested groups the plan creates. MemberName is the
+// username of user members and the display name of nested groups, or their
+// ID when UAA no longer knows them.
+type GroupChange struct {
+	Type        GroupChangeType
+	Group       string
+	GroupID     string
+	Description string
+	Member      GroupMember
+	MemberName  string
+}
+
+func (change GroupChange) String() string {
+	switch change.Type {
+	case CreateGroupChange:
+		return fmt.Sprintf("+ group %s", change.Group)
+	case AddMemberChange:
+		return fmt.Sprintf("+ %s %s in %s", strings.ToLower(change.Member.Type), change.MemberName, change.Group)
+	case RemoveMemberChange:
+		return fmt.Sprintf("- %s %s in %s", strings.ToLower(change.Member.Type), change.MemberName, change.Group)
+	}
+	return fmt.Sprintf("%s %s", change.Type, change.Group)
+}
+
+// Changes that bring the groups of UAA to the desired state, groups to
+// create first
+type GroupsPlan struct {
+	Changes []GroupChange
+}
+
+func (plan GroupsPlan) IsEmpty() bool {
+	return len(plan.Changes) == 0
+}
+
+// Lists the changes one per line, like "+ user jane in notifications.write"
+func (plan GroupsPlan) String() string {
+	if plan.IsEmpty() {
+		return "No changes"
+	}
+
+	lines := []string{}
+	for _, change := range plan.Changes {
+		lines = append(lines, change.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returned by PlanGroups when desired members do not match exactly one user
+// or group. Users are named "username" or "username (origin)".
+type UnresolvedMembersError struct {
+	NotFound  []string
+	Ambiguous []string
+}
+
+func (err UnresolvedMembersError) Error() string {
+	problems := []string{}
+	if len(err.NotFound) != 0 {
+		problems = append(problems, "not found: "+strings.Join(err.NotFound, ", "))
+	}
+	if len(err.Ambiguous) != 0 {
+		problems = append(problems, "in several origins: "+strings.Join(err.Ambiguous, ", "))
+	}
+	return "Unresolved group members, " + strings.Join(problems, "; ")
+}
+
+// Reads the desired groups and users from UAA and plans the changes that
+// give each desired group exactly the desired members. Groups that are not
+// desired are left alone, and members are compared by ID, so renamed users
+// are not churned. A group listed twice gets the members of both entries.
+func PlanGroups(u UAA, desired []DesiredGroup) (_ GroupsPlan, err error) {
+	u, span := u.withOperation("PlanGroups")
+	defer span.end(&err)
+
+	plan := GroupsPlan{Changes: []GroupChange{}}
+	desired = mergeDesiredGroups(desired)
+
+	names := []string{}
+	for _, group := range desired {
+		names = append(names, group.DisplayName)
+		names = append(names, group.Groups...)
+	}
+
+	existing, err := groupsByDisplayNames(u, names)
+	if err != nil {
+		return plan, err
+	}
+
+	users, unresolved, err := resolveDesiredUsers(u, desired)
+	if err != nil {
+		return plan, err
+	}
+
+	desiredNames := map[string]bool{}
+	for _, group := range desired {
+		desiredNames[strings.ToLower(group.DisplayName)] = true
+	}
+	for _, name := range uniqueStrings(names) {
+		_, found := existing[strings.ToLower(name)]
+		if !found && !desiredNames[strings.ToLower(name)] {
+			unresolved.NotFound = append(unresolved.NotFound, "group "+name)
+		}
+	}
+
+	if len(unresolved.NotFound) != 0 || len(unresolved.Ambiguous) != 0 {
+		return plan, unresolved
+	}
+
+	groupNames := map[string]string{}
+	for _, group := range existing {
+		groupNames[group.ID] = group.DisplayName
+	}
+
+	var creates, changes []GroupChange
+	var removedUserIDs []string
+	for _, desiredGroup := range desired {
+		group, found := existing[strings.ToLower(desiredGroup.DisplayName)]
+		if !found {
+			creates = append(creates, GroupChange{
+				Type:        CreateGroupChange,
+				Group:       desiredGroup.DisplayName,
+				Description: desiredGroup.Description,
+			})
+			group = Group{DisplayName: desiredGroup.DisplayName}
+		}
+
+		wanted := map[string]bool{}
+		add := func(member GroupMember, name string) {
+			key := member.Type + " " + member.Value
+			if member.Value == "" {
+				key = member.Type + " name " + strings.ToLower(name)
+			}
+			if wanted[key] {
+				return
+			}
+			wanted[key] = true
+
+			for _, current := range group.Members {
+				if current.Value == member.Value && memberType(current) == member.Type {
+					return
+				}
+			}
+
+			changes = append(changes, GroupChange{
+				Type:       AddMemberChange,
+				Group:      group.DisplayName,
+				GroupID:    group.ID,
+				Member:     member,
+				MemberName: name,
+			})
+		}
+
+		for _, desiredUser := range desiredGroup.Users {
+			user := users[desiredUserKey(desiredUser)]
+			add(GroupMember{Value: user.ID, Type: GroupMemberUser, Origin: user.Origin}, user.Username)
+		}
+
+		for _, name := range desiredGroup.Groups {
+			nested := existing[strings.ToLower(name)]
+			add(GroupMember{Value: nested.ID, Type: GroupMemberGroup}, name)
+		}
+
+		for _, current := range group.Members {
+			if wanted[memberType(current)+" "+current.Value] {
+				continue
+			}
+
+			name := current.Value
+			if memberType(current) == GroupMemberUser {
+				removedUserIDs = append(removedUserIDs, current.Value)
+			} else if groupName, ok := groupNames[current.Value]; ok {
+				name = groupName
+			}
+
+			changes = append(changes, GroupChange{
+				Type:       RemoveMemberChange,
+				Group:      group.DisplayName,
+				GroupID:    group.ID,
+				Member:     current,
+				MemberName: name,
+			})
+		}
+	}
+
+	if len(removedUserIDs) != 0 {
+		lookup, err := LookupUsersByIDs(u, removedUserIDs...)
+		if err != nil {
+			return plan, err
+		}
+
+		for i, change := range changes {
+			if user, ok := lookup.Users[change.Member.Value]; ok && change.Type == RemoveMemberChange && memberType(change.Member) == GroupMemberUser {
+				changes[i].MemberName = user.Username
+			}
+		}
+	}
+
+	plan.Changes = append(append(plan.Changes, creates...), changes...)
+	return plan, nil
+}
+
+type ApplyGroupsOptions struct {
+	// Reports the changes without making them
+	DryRun bool
+
+	// Changes made at once, DefaultApplyGroupsConcurrency when zero
+	Concurrency int
+}
+
+// Outcome of a planned change. Changes are not applied in a dry run, or when
+// they could not be made, see Err.
+type GroupChangeResult struct {
+	Change  GroupChange
+	Applied bool
+	Err     error
+}
+
+func (result GroupChangeResult) String() string {
+	switch {
+	case result.Err != nil:
+		return fmt.Sprintf("%s: failed: %s", result.Change, result.Err)
+	case result.Applied:
+		return fmt.Sprintf("%s: applied", result.Change)
+	}
+	return fmt.Sprintf("%s: planned", result.Change)
+}
+
+// Result of each change of a plan, in the order of the plan
+type GroupsApplyReport struct {
+	DryRun  bool
+	Results []GroupChangeResult
+}
+
+func (report GroupsApplyReport) Failed() []GroupChangeResult {
+	failed := []GroupChangeResult{}
+	for _, result := range report.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+func (report GroupsApplyReport) String() string {
+	lines := []string{}
+	for _, result := range report.Results {
+		lines = append(lines, result.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Makes the changes of the plan, creating groups before changing members so
+// that groups created by the plan can be nested. A failed change does not
+// stop the others, except the ones that need a group that could not be
+// created. The returned error wraps the first failure when any change failed.
+func ApplyGroupsPlan(u UAA, plan GroupsPlan, options ApplyGroupsOptions) (_ GroupsApplyReport, err error) {
+	u, span := u.withOperation("ApplyGroupsPlan")
+	defer span.end(&err)
+
+	report := GroupsApplyReport{DryRun: options.DryRun, Results: []GroupChangeResult{}}
+	for _, change := range plan.Changes {
+		report.Results = append(report.Results, GroupChangeResult{Change: change})
+	}
+
+	if options.DryRun {
+		return report, nil
+	}
+
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultApplyGroupsConcurrency
+	}
+
+	var creates, changes []int
+	for i, change := range plan.Changes {
+		if change.Type == CreateGroupChange {
+			creates = append(creates, i)
+		} else {
+			changes = append(changes, i)
+		}
+	}
+
+	var mutex sync.Mutex
+	createdIDs := map[string]string{}
+
+	err = runConcurrently(u, len(creates), concurrency, func(u UAA, i int) error {
+		result := &report.Results[creates[i]]
+
+		created, err := CreateGroup(u, Group{DisplayName: result.Change.Group, Description: result.Change.Description})
+		if err != nil {
+			result.Err = err
+			return nil
+		}
+
+		mutex.Lock()
+		createdIDs[strings.ToLower(created.DisplayName)] = created.ID
+		mutex.Unlock()
+
+		result.Change.GroupID = created.ID
+		result.Applied = true
+		return nil
+	})
+	if err != nil {
+		return report, abortApply(report, err)
+	}
+
+	err = runConcurrently(u, len(changes), concurrency, func(u UAA, i int) error {
+		result := &report.Results[changes[i]]
+		change := &result.Change
+
+		if change.GroupID == "" {
+			change.GroupID = createdIDs[strings.ToLower(change.Group)]
+		}
+		if change.Member.Value == "" && change.Member.Type == GroupMemberGroup {
+			change.Member.Value = createdIDs[strings.ToLower(change.MemberName)]
+		}
+
+		switch {
+		case change.GroupID == "":
+			result.Err = fmt.Errorf("group %s was not created", change.Group)
+		case change.Member.Value == "":
+			result.Err = fmt.Errorf("group %s was not created", change.MemberName)
+		case change.Type == AddMemberChange:
+			_, result.Err = AddGroupMember(u, change.GroupID, change.Member)
+		case change.Type == RemoveMemberChange:
+			result.Err = RemoveGroupMember(u, change.GroupID, change.Member.Value)
+		default:
+			result.Err = fmt.Errorf("unknown group change %q", change.Type)
+		}
+
+		result.Applied = result.Err == nil
+		return nil
+	})
+	if err != nil {
+		return report, abortApply(report, err)
+	}
+
+	failed := report.Failed()
+	if len(failed) != 0 {
+		return report, fmt.Errorf("%d of %d group changes failed, first %s: %w", len(failed), len(report.Results), failed[0].Change, failed[0].Err)
+	}
+
+	return report, nil
+}
+
+// Marks the changes that were not made before the context of the UAA was
+// canceled as failed with err
+func abortApply(report GroupsApplyReport, err error) error {
+	for i, result := range report.Results {
+		if !result.Applied && result.Err == nil {
+			report.Results[i].Err = err
+		}
+	}
+	return err
+}
+
+// Keys the groups with the given display names by their lowercased display
+// name
+func groupsByDisplayNames(u UAA, names []string) (map[string]Group, error) {
+	groups := map[string]Group{}
+
+	names = uniqueStrings(lowerStrings(names))
+	for start := 0; start < len(names); start += groupsPerQuery {
+		end := start + groupsPerQuery
+		if end > len(names) {
+			end = len(names)
+		}
+
+		var filters []Filter
+		for _, name := range names[start:end] {
+			filters = append(filters, Eq("displayName", name))
+		}
+
+		found, err := AllGroups(u, Or(filters...))
+		if err != nil {
+			return groups, err
+		}
+
+		for _, group := range found {
+			groups[strings.ToLower(group.DisplayName)] = group
+		}
+	}
+
+	return groups, nil
+}
+
+// Finds the user of every desired user, keyed by desiredUserKey
+func resolveDesiredUsers(u UAA, desired []DesiredGroup) (map[string]User, UnresolvedMembersError, error) {
+	users := map[string]User{}
+	unresolved := UnresolvedMembersError{NotFound: []string{}, Ambiguous: []string{}}
+
+	usernamesByOrigin := map[string][]string{}
+	origins := []string{}
+	for _, group := range desired {
+		for _, user := range group.Users {
+			if _, ok := usernamesByOrigin[user.Origin]; !ok {
+				origins = append(origins, user.Origin)
+			}
+			usernamesByOrigin[user.Origin] = append(usernamesByOrigin[user.Origin], user.Username)
+		}
+	}
+
+	for _, origin := range origins {
+		usernames := uniqueStrings(usernamesByOrigin[origin])
+
+		if origin == "" {
+			lookup, err := LookupUsersByUsernames(u, usernames...)
+			if err != nil {
+				return users, unresolved, err
+			}
+
+			for _, username := range usernames {
+				key := desiredUserKey(DesiredUser{Username: username})
+				if user, ok := lookup.Users[username]; ok {
+					users[key] = user
+				} else if _, ok := lookup.Duplicates[username]; ok {
+					unresolved.Ambiguous = append(unresolved.Ambiguous, username)
+				} else {
+					unresolved.NotFound = append(unresolved.NotFound, username)
+				}
+			}
+			continue
+		}
+
+		found, err := UsersByUsernamesInOrigin(u, origin, usernames...)
+		if err != nil {
+			return users, unresolved, err
+		}
+
+		for _, user := range found {
+			users[desiredUserKey(DesiredUser{Username: user.Username, Origin: origin})] = user
+		}
+
+		for _, username := range usernames {
+			if _, ok := users[desiredUserKey(DesiredUser{Username: username, Origin: origin})]; !ok {
+				unresolved.NotFound = append(unresolved.NotFound, fmt.Sprintf("%s (%s)", username, origin))
+			}
+		}
+	}
+
+	return users, unresolved, nil
+}
+
+func desiredUserKey(user DesiredUser) string {
+	return user.Origin + " " + strings.ToLower(user.Username)
+}
+
+// Members without a type are users, as in UsersGUIDsByScope
+func memberType(member GroupMember) string {
+	if member.Type == "" {
+		return GroupMemberUser
+	}
+	return member.Type
+}
+
+func mergeDesiredGroups(desired []DesiredGroup) []DesiredGroup {
+	merged := []DesiredGroup{}
+	indexes := map[string]int{}
+	for _, group := range desired {
+		key := strings.ToLower(group.DisplayName)
+		i, ok := indexes[key]
+		if !ok {
+			indexes[key] = len(merged)
+			merged = append(merged, group)
+			continue
+		}
+
+		merged[i].Users = append(append([]DesiredUser{}, merged[i].Users...), group.Users...)
+		merged[i].Groups = append(append([]string{}, merged[i].Groups...), group.Groups...)
+		if merged[i].Description == "" {
+			merged[i].Description = group.Description
+		}
+	}
+	return merged
+}
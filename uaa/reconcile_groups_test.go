@@ -0,0 +1,350 @@
+package uaa_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reconciling groups", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var mutex sync.Mutex
+	var groups map[string]map[string]interface{}
+	var requests []*http.Request
+	var inFlight, maxInFlight int
+	var failingGroupID string
+
+	usersList := []map[string]interface{}{
+		{"id": "user-jane", "userName": "jane", "origin": "uaa"},
+		{"id": "ldap-jane", "userName": "jane", "origin": "ldap"},
+		{"id": "user-john", "userName": "john", "origin": "uaa"},
+		{"id": "user-old", "userName": "old", "origin": "uaa"},
+	}
+
+	changeRequests := func() []string {
+		changes := []string{}
+		for _, req := range requests {
+			if req.Method != "GET" {
+				changes = append(changes, req.Method+" "+req.URL.Path)
+			}
+		}
+		return changes
+	}
+
+	listResponse := func(resources interface{}, count int) []byte {
+		response, err := json.Marshal(map[string]interface{}{
+			"resources":    resources,
+			"startIndex":   1,
+			"itemsPerPage": count,
+			"totalResults": count,
+		})
+		if err != nil {
+			panic(err)
+		}
+		return response
+	}
+
+	BeforeEach(func() {
+		requests = []*http.Request{}
+		inFlight, maxInFlight = 0, 0
+		failingGroupID = ""
+		groups = map[string]map[string]interface{}{
+			"group-write": {
+				"id":          "group-write",
+				"displayName": "notifications.write",
+				"members": []interface{}{
+					map[string]interface{}{"value": "user-john", "type": "USER", "origin": "uaa"},
+					map[string]interface{}{"value": "user-old", "type": "USER", "origin": "uaa"},
+				},
+			},
+			"group-admin": {
+				"id":          "group-admin",
+				"displayName": "notifications.admin",
+				"members":     []interface{}{},
+			},
+		}
+
+		fakeUAAServer = httptest.NewServer(recordRequests(&requests, nil, func(w http.ResponseWriter, req *http.Request) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				panic(err)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			filter := req.URL.Query().Get("filter")
+			values := func(attribute string) map[string]bool {
+				values := map[string]bool{}
+				for _, match := range regexp.MustCompile(attribute+` eq "([^"]*)"`).FindAllStringSubmatch(filter, -1) {
+					values[strings.ToLower(match[1])] = true
+				}
+				return values
+			}
+
+			path := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+			switch {
+			case req.Method == "GET" && req.URL.Path == "/Users":
+				ids, usernames, origins := values("Id"), values("userName"), values("origin")
+				resources := []map[string]interface{}{}
+				for _, user := range usersList {
+					if (ids[user["id"].(string)] || usernames[user["userName"].(string)]) && (len(origins) == 0 || origins[user["origin"].(string)]) {
+						resources = append(resources, user)
+					}
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write(listResponse(resources, len(resources)))
+			case req.Method == "GET" && req.URL.Path == "/Groups":
+				names := values("displayName")
+				resources := []map[string]interface{}{}
+				for _, id := range []string{"group-write", "group-admin", "group-notifications.read", "group-notifications.all"} {
+					if group, ok := groups[id]; ok && names[strings.ToLower(group["displayName"].(string))] {
+						resources = append(resources, group)
+					}
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write(listResponse(resources, len(resources)))
+			case req.Method == "POST" && req.URL.Path == "/Groups":
+				var group map[string]interface{}
+				json.Unmarshal(body, &group)
+				if group["displayName"] == "fail.create" {
+					w.WriteHeader(http.StatusInternalServerError)
+					w.Write([]byte(`{"error":"server_error"}`))
+					return
+				}
+				group["id"] = "group-" + group["displayName"].(string)
+				group["members"] = []interface{}{}
+				groups[group["id"].(string)] = group
+				response, _ := json.Marshal(group)
+				w.WriteHeader(http.StatusCreated)
+				w.Write(response)
+			case len(path) >= 3 && path[0] == "Groups" && path[2] == "members":
+				group, ok := groups[path[1]]
+				if !ok || path[1] == failingGroupID {
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"error":"scim_resource_not_found","error_description":"Group does not exist"}`))
+					return
+				}
+
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				mutex.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mutex.Lock()
+				inFlight--
+
+				members := group["members"].([]interface{})
+				if req.Method == "POST" {
+					var member map[string]interface{}
+					json.Unmarshal(body, &member)
+					group["members"] = append(members, member)
+					w.WriteHeader(http.StatusCreated)
+					w.Write(body)
+					return
+				}
+
+				kept := []interface{}{}
+				for _, member := range members {
+					if member.(map[string]interface{})["value"] != path[3] {
+						kept = append(kept, member)
+					}
+				}
+				group["members"] = kept
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	desired := []uaa.DesiredGroup{
+		{
+			DisplayName: "notifications.write",
+			Users:       []uaa.DesiredUser{{Username: "jane", Origin: "uaa"}, {Username: "John"}},
+			Groups:      []string{"notifications.admin"},
+		},
+		{
+			DisplayName: "notifications.read",
+			Description: "Read notifications",
+			Users:       []uaa.DesiredUser{{Username: "jane", Origin: "ldap"}},
+			Groups:      []string{"notifications.write"},
+		},
+		{
+			DisplayName: "notifications.all",
+			Groups:      []string{"notifications.read"},
+		},
+	}
+
+	Describe("PlanGroups", func() {
+		It("plans the changes that bring the groups to the desired state", func() {
+			plan, err := uaa.PlanGroups(auth, desired)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(plan.Changes).To(Equal([]uaa.GroupChange{
+				{Type: uaa.CreateGroupChange, Group: "notifications.read", Description: "Read notifications"},
+				{Type: uaa.CreateGroupChange, Group: "notifications.all"},
+				{
+					Type: uaa.AddMemberChange, Group: "notifications.write", GroupID: "group-write",
+					Member: uaa.GroupMember{Value: "user-jane", Type: uaa.GroupMemberUser, Origin: "uaa"}, MemberName: "jane",
+				},
+				{
+					Type: uaa.AddMemberChange, Group: "notifications.write", GroupID: "group-write",
+					Member: uaa.GroupMember{Value: "group-admin", Type: uaa.GroupMemberGroup}, MemberName: "notifications.admin",
+				},
+				{
+					Type: uaa.RemoveMemberChange, Group: "notifications.write", GroupID: "group-write",
+					Member: uaa.GroupMember{Value: "user-old", Type: uaa.GroupMemberUser, Origin: "uaa"}, MemberName: "old",
+				},
+				{
+					Type: uaa.AddMemberChange, Group: "notifications.read",
+					Member: uaa.GroupMember{Value: "ldap-jane", Type: uaa.GroupMemberUser, Origin: "ldap"}, MemberName: "jane",
+				},
+				{
+					Type: uaa.AddMemberChange, Group: "notifications.read",
+					Member: uaa.GroupMember{Value: "group-write", Type: uaa.GroupMemberGroup}, MemberName: "notifications.write",
+				},
+				{
+					Type: uaa.AddMemberChange, Group: "notifications.all",
+					Member: uaa.GroupMember{Type: uaa.GroupMemberGroup}, MemberName: "notifications.read",
+				},
+			}))
+
+			Expect(plan.String()).To(Equal(strings.Join([]string{
+				"+ group notifications.read",
+				"+ group notifications.all",
+				"+ user jane in notifications.write",
+				"+ group notifications.admin in notifications.write",
+				"- user old in notifications.write",
+				"+ user jane in notifications.read",
+				"+ group notifications.write in notifications.read",
+				"+ group notifications.read in notifications.all",
+			}, "\n")))
+			Expect(changeRequests()).To(BeEmpty())
+		})
+
+		It("plans nothing when the groups are in the desired state", func() {
+			plan, err := uaa.PlanGroups(auth, []uaa.DesiredGroup{
+				{DisplayName: "notifications.write", Users: []uaa.DesiredUser{{Username: "john"}}},
+				{DisplayName: "notifications.write", Users: []uaa.DesiredUser{{Username: "old", Origin: "uaa"}}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(plan.IsEmpty()).To(BeTrue())
+			Expect(plan.String()).To(Equal("No changes"))
+		})
+
+		It("reports members that match no user or group, or users in several origins", func() {
+			plan, err := uaa.PlanGroups(auth, []uaa.DesiredGroup{{
+				DisplayName: "notifications.write",
+				Users:       []uaa.DesiredUser{{Username: "jane"}, {Username: "ghost"}, {Username: "john", Origin: "ldap"}},
+				Groups:      []string{"notifications.nope"},
+			}})
+
+			Expect(err).To(Equal(uaa.UnresolvedMembersError{
+				NotFound:  []string{"ghost", "john (ldap)", "group notifications.nope"},
+				Ambiguous: []string{"jane"},
+			}))
+			Expect(err.Error()).To(Equal("Unresolved group members, not found: ghost, john (ldap), group notifications.nope; in several origins: jane"))
+			Expect(plan.IsEmpty()).To(BeTrue())
+		})
+	})
+
+	Describe("ApplyGroupsPlan", func() {
+		It("reports the changes without making them in a dry run", func() {
+			plan, err := uaa.PlanGroups(auth, desired)
+			Expect(err).NotTo(HaveOccurred())
+
+			report, err := uaa.ApplyGroupsPlan(auth, plan, uaa.ApplyGroupsOptions{DryRun: true})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(changeRequests()).To(BeEmpty())
+			Expect(report.DryRun).To(BeTrue())
+			Expect(report.Results).To(HaveLen(8))
+			Expect(report.Results[0].String()).To(Equal("+ group notifications.read: planned"))
+			Expect(report.Failed()).To(BeEmpty())
+		})
+
+		It("applies the plan, creating groups before nesting them", func() {
+			plan, err := uaa.PlanGroups(auth, desired)
+			Expect(err).NotTo(HaveOccurred())
+
+			report, err := uaa.ApplyGroupsPlan(auth, plan, uaa.ApplyGroupsOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report.Failed()).To(BeEmpty())
+			for _, result := range report.Results {
+				Expect(result.Applied).To(BeTrue())
+			}
+			Expect(report.Results[0].Change.GroupID).To(Equal("group-notifications.read"))
+			Expect(report.Results[7].Change.Member.Value).To(Equal("group-notifications.read"))
+			Expect(report.Results[2].String()).To(Equal("+ user jane in notifications.write: applied"))
+			Expect(changeRequests()[:2]).To(ConsistOf("POST /Groups", "POST /Groups"))
+
+			plan, err = uaa.PlanGroups(auth, desired)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.IsEmpty()).To(BeTrue())
+		})
+
+		It("limits the changes made at once", func() {
+			plan, err := uaa.PlanGroups(auth, desired)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = uaa.ApplyGroupsPlan(auth, plan, uaa.ApplyGroupsOptions{Concurrency: 2})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(maxInFlight).To(Equal(2))
+		})
+
+		It("keeps going after a failed change and reports it", func() {
+			failingGroupID = "group-write"
+			plan, err := uaa.PlanGroups(auth, desired)
+			Expect(err).NotTo(HaveOccurred())
+
+			report, err := uaa.ApplyGroupsPlan(auth, plan, uaa.ApplyGroupsOptions{})
+
+			Expect(uaa.IsNotFound(err)).To(BeTrue())
+			Expect(err.Error()).To(HavePrefix("3 of 8 group changes failed, first + user jane in notifications.write: UAA Failure: 404"))
+
+			failed := report.Failed()
+			Expect(failed).To(HaveLen(3))
+			Expect(failed[0].Change.Group).To(Equal("notifications.write"))
+			Expect(failed[0].String()).To(HavePrefix("+ user jane in notifications.write: failed: UAA Failure: 404"))
+			Expect(report.Results[5].Applied).To(BeTrue())
+		})
+
+		It("fails the changes that need a group that could not be created", func() {
+			plan, err := uaa.PlanGroups(auth, []uaa.DesiredGroup{
+				{DisplayName: "fail.create", Users: []uaa.DesiredUser{{Username: "john"}}},
+				{DisplayName: "notifications.admin", Groups: []string{"fail.create"}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			report, err := uaa.ApplyGroupsPlan(auth, plan, uaa.ApplyGroupsOptions{})
+
+			Expect(err).To(HaveOccurred())
+			Expect(report.Failed()).To(HaveLen(3))
+			Expect(report.Results[0].Err).To(BeAssignableToTypeOf(uaa.Failure{}))
+			Expect(report.Results[1].Err).To(MatchError("group fail.create was not created"))
+			Expect(report.Results[2].Err).To(MatchError("group fail.create was not created"))
+		})
+	})
+})
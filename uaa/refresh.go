@@ -11,7 +11,10 @@ type RefreshInterface interface {
 	Refresh(string) (Token, error)
 }
 
-func Refresh(u UAA, refreshToken string) (Token, error) {
+func Refresh(u UAA, refreshToken string) (_ Token, err error) {
+	u, span := u.withOperation("Refresh")
+	defer span.end(&err)
+
 	token := NewToken()
 	params := url.Values{
 		"grant_type":    {"refresh_token"},
@@ -24,8 +27,7 @@ func Refresh(u UAA, refreshToken string) (Token, error) {
 		return token, err
 	}
 
-	host := uri.Scheme + "://" + uri.Host
-	client := NewClient(host, u.VerifySSL).WithBasicAuthCredentials(u.ClientID, u.ClientSecret)
+	client := u.newClient(uri).WithBasicAuthCredentials(u.ClientID, u.ClientSecret)
 	code, body, err := client.MakeRequest("POST", uri.RequestURI(), strings.NewReader(params.Encode()))
 	if err != nil {
 		return token, err
@@ -0,0 +1,154 @@
+package uaa
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Describes how a Client retries requests that fail transiently. The zero
+// value makes a single attempt, use DefaultRetryPolicy for sensible retries.
+type RetryPolicy struct {
+	// Total number of attempts, including the first one
+	MaxAttempts int
+
+	// Delay before the first retry, doubled for every retry after that
+	BaseDelay time.Duration
+
+	// Upper bound for any single delay, including one asked for by Retry-After
+	MaxDelay time.Duration
+}
+
+// Returns a RetryPolicy making up to 3 attempts with jittered exponential backoff
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+	}
+}
+
+// Details about a retry that is about to happen, passed to Hooks.OnRetry
+type RetryEvent struct {
+	Method     string
+	Path       string
+	Attempt    int
+	StatusCode int
+	Err        error
+	Delay      time.Duration
+}
+
+// Callbacks a Client invokes while making requests
+type Hooks struct {
+	// Called before every retry, Attempt is the number of the attempt about to be made
+	OnRetry func(RetryEvent)
+}
+
+func (hooks Hooks) onRetry(event RetryEvent) {
+	if hooks.OnRetry != nil {
+		hooks.OnRetry(event)
+	}
+}
+
+// Returns the jittered delay before the given retry, retries are numbered from 1
+func (policy RetryPolicy) Backoff(retry int) time.Duration {
+	delay := policy.BaseDelay
+	for i := 1; i < retry && (policy.MaxDelay <= 0 || delay < policy.MaxDelay); i++ {
+		delay *= 2
+	}
+	delay = policy.capDelay(delay)
+
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+func (policy RetryPolicy) capDelay(delay time.Duration) time.Duration {
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		return policy.MaxDelay
+	}
+	return delay
+}
+
+func (policy RetryPolicy) delay(retry int, code int, header http.Header) time.Duration {
+	if code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable {
+		if delay, ok := retryAfter(header); ok {
+			return policy.capDelay(delay)
+		}
+	}
+	return policy.Backoff(retry)
+}
+
+// Parses a Retry-After header given either in seconds or as an HTTP date
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	delay := time.Until(date)
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+// Determines whether a failed attempt can be repeated. Idempotent requests
+// are retried on network errors and gateway failures, while all others only
+// when UAA cannot have acted on them: the connection was never established,
+// or UAA rate limited the request.
+func (client Client) shouldRetry(method string, code int, err error) bool {
+	idempotent := client.Idempotent || method == "GET" || method == "HEAD"
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return idempotent || isDialError(err)
+	}
+
+	switch code {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	}
+
+	return false
+}
+
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
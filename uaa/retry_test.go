@@ -0,0 +1,235 @@
+package uaa_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retries", func() {
+	var server *httptest.Server
+	var client uaa.Client
+	var requestCount int
+	var responseCodes []int
+	var retryAfterHeader string
+	var events []uaa.RetryEvent
+
+	BeforeEach(func() {
+		requestCount = 0
+		responseCodes = []int{}
+		retryAfterHeader = ""
+		events = []uaa.RetryEvent{}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			requestCount += 1
+			code := http.StatusOK
+			if requestCount <= len(responseCodes) {
+				code = responseCodes[requestCount-1]
+			}
+			if retryAfterHeader != "" {
+				w.Header().Set("Retry-After", retryAfterHeader)
+			}
+			w.WriteHeader(code)
+			w.Write([]byte(req.Method + " " + req.URL.Path))
+		}))
+
+		client = uaa.NewClient(server.URL, false).
+			WithAuthorizationToken("my-special-token").
+			WithRetryPolicy(uaa.RetryPolicy{
+				MaxAttempts: 3,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    10 * time.Millisecond,
+			}).
+			WithHooks(uaa.Hooks{
+				OnRetry: func(event uaa.RetryEvent) {
+					events = append(events, event)
+				},
+			})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("when the policy is the zero value", func() {
+		It("makes a single attempt", func() {
+			responseCodes = []int{http.StatusServiceUnavailable}
+			client.RetryPolicy = uaa.RetryPolicy{}
+
+			code, _, err := client.MakeRequest("GET", "/something", nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(code).To(Equal(http.StatusServiceUnavailable))
+			Expect(requestCount).To(Equal(1))
+		})
+	})
+
+	Context("with GET requests", func() {
+		It("retries bad gateway and service unavailable responses until one succeeds", func() {
+			responseCodes = []int{http.StatusBadGateway, http.StatusServiceUnavailable}
+
+			code, body, err := client.MakeRequest("GET", "/something", nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(string(body)).To(Equal("GET /something"))
+			Expect(requestCount).To(Equal(3))
+		})
+
+		It("gives up after the maximum number of attempts", func() {
+			responseCodes = []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK}
+
+			code, _, err := client.MakeRequest("GET", "/something", nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(code).To(Equal(http.StatusBadGateway))
+			Expect(requestCount).To(Equal(3))
+		})
+
+		It("does not retry client errors", func() {
+			responseCodes = []int{http.StatusNotFound}
+
+			code, _, err := client.MakeRequest("GET", "/something", nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(code).To(Equal(http.StatusNotFound))
+			Expect(requestCount).To(Equal(1))
+		})
+
+		It("retries network errors", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				panic(err)
+			}
+			address := listener.Addr().String()
+			listener.Close()
+
+			client.Host = "http://" + address
+			_, _, err = client.MakeRequest("GET", "/something", nil)
+
+			Expect(err).To(HaveOccurred())
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].Err).To(HaveOccurred())
+		})
+	})
+
+	Context("with POST requests", func() {
+		It("does not retry gateway failures by default", func() {
+			responseCodes = []int{http.StatusServiceUnavailable}
+
+			code, _, err := client.MakeRequest("POST", "/oauth/token", strings.NewReader("grant_type=authorization_code"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(code).To(Equal(http.StatusServiceUnavailable))
+			Expect(requestCount).To(Equal(1))
+		})
+
+		It("retries gateway failures when the client is marked idempotent", func() {
+			responseCodes = []int{http.StatusServiceUnavailable}
+
+			code, body, err := client.AsIdempotent().MakeRequest("POST", "/oauth/token", strings.NewReader("grant_type=client_credentials"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(string(body)).To(Equal("POST /oauth/token"))
+			Expect(requestCount).To(Equal(2))
+		})
+
+		It("retries rate limited requests, since UAA did not act on them", func() {
+			responseCodes = []int{http.StatusTooManyRequests}
+
+			code, _, err := client.MakeRequest("POST", "/oauth/token", strings.NewReader("grant_type=authorization_code"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(requestCount).To(Equal(2))
+		})
+	})
+
+	Describe("Retry-After", func() {
+		It("waits for as long as UAA asks on rate limited requests", func() {
+			responseCodes = []int{http.StatusTooManyRequests}
+			retryAfterHeader = "0"
+			client.RetryPolicy.BaseDelay = time.Hour
+			client.RetryPolicy.MaxDelay = 0
+
+			code, _, err := client.MakeRequest("GET", "/something", nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Delay).To(Equal(time.Duration(0)))
+		})
+
+		It("never waits longer than the maximum delay", func() {
+			responseCodes = []int{http.StatusServiceUnavailable}
+			retryAfterHeader = "120"
+
+			_, _, err := client.MakeRequest("GET", "/something", nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Delay).To(Equal(10 * time.Millisecond))
+		})
+	})
+
+	Describe("Hooks", func() {
+		It("reports every retry", func() {
+			responseCodes = []int{http.StatusBadGateway, http.StatusGatewayTimeout}
+
+			client.MakeRequest("GET", "/something", nil)
+
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].Method).To(Equal("GET"))
+			Expect(events[0].Path).To(Equal("/something"))
+			Expect(events[0].Attempt).To(Equal(2))
+			Expect(events[0].StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(events[1].Attempt).To(Equal(3))
+			Expect(events[1].StatusCode).To(Equal(http.StatusGatewayTimeout))
+		})
+	})
+
+	Describe("Backoff", func() {
+		It("doubles the delay for every retry, keeping it within the jitter range", func() {
+			policy := uaa.RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+			for i := 0; i < 20; i++ {
+				Expect(policy.Backoff(1)).To(BeNumerically(">=", 50*time.Millisecond))
+				Expect(policy.Backoff(1)).To(BeNumerically("<=", 100*time.Millisecond))
+				Expect(policy.Backoff(3)).To(BeNumerically(">=", 200*time.Millisecond))
+				Expect(policy.Backoff(3)).To(BeNumerically("<=", 400*time.Millisecond))
+				Expect(policy.Backoff(10)).To(BeNumerically("<=", time.Second))
+			}
+		})
+	})
+
+	Describe("GetClientToken", func() {
+		It("is retried using the UAA retry policy", func() {
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requestCount += 1
+				if requestCount == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"access_token": "client-access-token"}`))
+			})
+
+			auth := uaa.NewUAA("", server.URL, "the-client-id", "the-client-secret", "")
+			auth.RetryPolicy = uaa.RetryPolicy{MaxAttempts: 2}
+
+			token, err := uaa.GetClientToken(auth)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.Access).To(Equal("client-access-token"))
+			Expect(requestCount).To(Equal(2))
+		})
+	})
+})
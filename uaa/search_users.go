@@ -0,0 +1,50 @@
+package uaa
+
+import "net/url"
+
+type SearchUsersInterface interface {
+	SearchUsers(UsersQuery) (UsersPage, error)
+}
+
+// Query for a page of users. Attributes limits the attributes UAA returns,
+// like []string{"id", "emails"}. StartIndex is 1-based, and zero values are
+// left to UAA.
+type UsersQuery struct {
+	Filter     Filter
+	SortBy     string
+	SortOrder  string
+	Attributes []string
+	StartIndex int
+	Count      int
+}
+
+func (query UsersQuery) values() url.Values {
+	return listQuery(query).values()
+}
+
+type UsersPage struct {
+	Users        []User
+	StartIndex   int
+	ItemsPerPage int
+	TotalResults int
+}
+
+// Returns a single page of the users matching the query, use StartIndex to
+// request the next ones
+func SearchUsers(u UAA, query UsersQuery) (_ UsersPage, err error) {
+	u, span := u.withOperation("SearchUsers")
+	defer span.end(&err)
+
+	users := []userJSON{}
+	response, err := u.listRequest("/Users", query.values(), &users)
+	if err != nil {
+		return UsersPage{Users: []User{}}, err
+	}
+
+	return UsersPage{
+		Users:        usersFromJSON(users),
+		StartIndex:   response.StartIndex,
+		ItemsPerPage: response.ItemsPerPage,
+		TotalResults: response.TotalResults,
+	}, nil
+}
@@ -0,0 +1,102 @@
+package uaa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SearchUsers", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var queries []url.Values
+
+	BeforeEach(func() {
+		queries = []url.Values{}
+		fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			queries = append(queries, req.URL.Query())
+
+			if req.URL.Path != "/Users" || req.Header.Get("Authorization") != "Bearer my-special-token" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			if req.URL.Query().Get("sortBy") == "password" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"scim_exception","error_description":"Invalid sort field: password"}`))
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+                "resources": [
+                    {"id": "091b6583-0933-4d17-a5b6-66e54666c88e", "emails": [{"value": "admin@corp.com"}]},
+                    {"id": "943e6076-b1a5-4404-811b-a1ee9253bf56", "emails": [{"value": "some-user@corp.com"}]}
+                ],
+                "startIndex": 3,
+                "itemsPerPage": 2,
+                "totalResults": 7,
+                "schemas": ["urn:scim:schemas:core:1.0"]
+            }`))
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	It("sends the query to UAA", func() {
+		_, err := uaa.SearchUsers(auth, uaa.UsersQuery{
+			Filter:     uaa.Co("emails.value", "@corp.com"),
+			SortBy:     "userName",
+			SortOrder:  "ascending",
+			Attributes: []string{"id", "emails"},
+			StartIndex: 3,
+			Count:      2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(queries).To(Equal([]url.Values{{
+			"filter":     {`emails.value co "@corp.com"`},
+			"sortBy":     {"userName"},
+			"sortOrder":  {"ascending"},
+			"attributes": {"id,emails"},
+			"startIndex": {"3"},
+			"count":      {"2"},
+		}}))
+	})
+
+	It("leaves unset options to UAA", func() {
+		_, err := uaa.SearchUsers(auth, uaa.UsersQuery{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(queries).To(Equal([]url.Values{{}}))
+	})
+
+	It("returns the page with its paging information", func() {
+		page, err := uaa.SearchUsers(auth, uaa.UsersQuery{Count: 2})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(page.StartIndex).To(Equal(3))
+		Expect(page.ItemsPerPage).To(Equal(2))
+		Expect(page.TotalResults).To(Equal(7))
+		Expect(page.Users).To(Equal([]uaa.User{
+			{ID: "091b6583-0933-4d17-a5b6-66e54666c88e", Emails: []string{"admin@corp.com"}},
+			{ID: "943e6076-b1a5-4404-811b-a1ee9253bf56", Emails: []string{"some-user@corp.com"}},
+		}))
+	})
+
+	It("returns failures", func() {
+		page, err := uaa.SearchUsers(auth, uaa.UsersQuery{SortBy: "password"})
+
+		Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+		Expect(err.(uaa.Failure).ErrorDescription()).To(Equal("Invalid sort field: password"))
+		Expect(page.Users).To(BeEmpty())
+	})
+})
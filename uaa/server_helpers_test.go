@@ -0,0 +1,33 @@
+package uaa_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Wraps the handler to record every request it serves along with its body,
+// in the order they arrive. Either slice may be nil when it is not needed.
+// Requests served concurrently are recorded one at a time.
+func recordRequests(requests *[]*http.Request, bodies *[]string, handler http.HandlerFunc) http.Handler {
+	var mutex sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			panic(err)
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		mutex.Lock()
+		if requests != nil {
+			*requests = append(*requests, req)
+		}
+		if bodies != nil {
+			*bodies = append(*bodies, string(body))
+		}
+		mutex.Unlock()
+
+		handler(w, req)
+	})
+}
@@ -0,0 +1,180 @@
+package uaa
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var InvalidTraceParent = errors.New("Invalid W3C traceparent")
+
+// W3C trace context identifying a span, see https://www.w3.org/TR/trace-context/
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// Determines if the trace and span IDs are set, as the specification requires
+func (spanContext SpanContext) IsValid() bool {
+	return spanContext.TraceID != [16]byte{} && spanContext.SpanID != [8]byte{}
+}
+
+// Formats the span context as a traceparent header value
+func (spanContext SpanContext) TraceParent() string {
+	flags := "00"
+	if spanContext.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(spanContext.TraceID[:]), hex.EncodeToString(spanContext.SpanID[:]), flags)
+}
+
+// Parses a traceparent header value
+func ParseTraceParent(traceParent string) (SpanContext, error) {
+	var spanContext SpanContext
+
+	traceParent = strings.TrimSpace(traceParent)
+	parts := strings.Split(traceParent, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return spanContext, InvalidTraceParent
+	}
+
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 || strings.ToLower(traceParent) != traceParent {
+		return spanContext, InvalidTraceParent
+	}
+
+	_, err := hex.Decode(spanContext.TraceID[:], []byte(parts[1]))
+	if err != nil {
+		return spanContext, InvalidTraceParent
+	}
+
+	_, err = hex.Decode(spanContext.SpanID[:], []byte(parts[2]))
+	if err != nil {
+		return spanContext, InvalidTraceParent
+	}
+
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return spanContext, InvalidTraceParent
+	}
+	spanContext.Sampled = flags[0]&1 == 1
+
+	if !spanContext.IsValid() {
+		return spanContext, InvalidTraceParent
+	}
+
+	return spanContext, nil
+}
+
+type spanContextKey struct{}
+
+// Returns a copy of the context carrying the given span context. Requests
+// made with it propagate the span context when the Client has no Tracer.
+func ContextWithSpanContext(ctx context.Context, spanContext SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, spanContext)
+}
+
+// Returns the span context carried by the context, if any
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	spanContext, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return spanContext, ok
+}
+
+// A span started by a Tracer for a UAA operation, or for a request made for one
+type Span interface {
+	// Returns the span context injected into the outgoing request
+	SpanContext() SpanContext
+
+	SetAttribute(key string, value interface{})
+
+	// Ends the span with the response status code, or the error when the
+	// request failed. Operation spans end with the status code of the last
+	// response received for the operation.
+	End(code int, err error)
+}
+
+// Starts spans for UAA operations, like "UserByID", and for every request
+// made for them, like "HTTP GET". The context passed in is the one given to
+// WithContext or MakeRequestWithContext, so a Tracer can find the parent span
+// in it; request spans get the context returned for their operation span.
+// Adapters for any tracing library can implement this interface.
+type Tracer interface {
+	StartSpan(ctx context.Context, operation string) (context.Context, Span)
+}
+
+type operationSpanKey struct{}
+
+// Span of a UAA operation, started by withOperation
+type operationSpan struct {
+	span  Span
+	mutex sync.Mutex
+	code  int
+}
+
+func operationSpanFromContext(ctx context.Context) *operationSpan {
+	opSpan, _ := ctx.Value(operationSpanKey{}).(*operationSpan)
+	return opSpan
+}
+
+// Records the status code of a response received for the operation, requests
+// may be made concurrently
+func (opSpan *operationSpan) observe(code int) {
+	if opSpan == nil {
+		return
+	}
+
+	opSpan.mutex.Lock()
+	defer opSpan.mutex.Unlock()
+	if code != 0 {
+		opSpan.code = code
+	}
+}
+
+// Ends the span with the error the operation returns, meant to be deferred
+func (opSpan *operationSpan) end(err *error) {
+	if opSpan == nil {
+		return
+	}
+
+	opSpan.mutex.Lock()
+	code := opSpan.code
+	opSpan.mutex.Unlock()
+	opSpan.span.End(code, *err)
+}
+
+// Starts a span for the request when the client has a Tracer, a child of the
+// operation span when ctx carries one, and works out the traceparent to send
+// along with it
+func (client Client) startSpan(ctx context.Context, method, path string) (context.Context, Span, string) {
+	if client.Tracer == nil {
+		spanContext, ok := SpanContextFromContext(ctx)
+		if !ok || !spanContext.IsValid() {
+			return ctx, nil, ""
+		}
+		return ctx, nil, spanContext.TraceParent()
+	}
+
+	name := client.operationName()
+	if operationSpanFromContext(ctx) != nil {
+		name = "HTTP " + method
+	}
+
+	// The query is left out of the span: SCIM filters carry usernames and
+	// emails, and tracers keep attributes as they are given
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+
+	ctx, span := client.Tracer.StartSpan(ctx, name)
+	span.SetAttribute("http.method", method)
+	span.SetAttribute("http.url", client.Host+path)
+
+	spanContext := span.SpanContext()
+	if !spanContext.IsValid() {
+		return ctx, span, ""
+	}
+	return ctx, span, spanContext.TraceParent()
+}
@@ -0,0 +1,203 @@
+package uaa_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeSpan struct {
+	operation   string
+	parent      context.Context
+	spanContext uaa.SpanContext
+	attributes  map[string]interface{}
+	endedWith   int
+	ended       bool
+}
+
+func (span *fakeSpan) SpanContext() uaa.SpanContext {
+	return span.spanContext
+}
+
+func (span *fakeSpan) SetAttribute(key string, value interface{}) {
+	span.attributes[key] = value
+}
+
+func (span *fakeSpan) End(code int, err error) {
+	span.ended = true
+	span.endedWith = code
+}
+
+type fakeSpanKey struct{}
+
+func fakeSpanFromContext(ctx context.Context) *fakeSpan {
+	span, _ := ctx.Value(fakeSpanKey{}).(*fakeSpan)
+	return span
+}
+
+type fakeTracer struct {
+	mutex sync.Mutex
+	spans []*fakeSpan
+}
+
+func (tracer *fakeTracer) StartSpan(ctx context.Context, operation string) (context.Context, uaa.Span) {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+
+	span := &fakeSpan{
+		operation:  operation,
+		parent:     ctx,
+		attributes: map[string]interface{}{},
+		spanContext: uaa.SpanContext{
+			TraceID: [16]byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+			SpanID:  [8]byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, byte(len(tracer.spans) + 1)},
+			Sampled: true,
+		},
+	}
+	tracer.spans = append(tracer.spans, span)
+	return context.WithValue(ctx, fakeSpanKey{}, span), span
+}
+
+var _ = Describe("Tracing", func() {
+	var fakeUAAServer *httptest.Server
+	var traceParents []string
+
+	BeforeEach(func() {
+		traceParents = []string{}
+		fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			traceParents = append(traceParents, req.Header.Get("traceparent"))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", "userName": "admin"}`))
+		}))
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	Context("with a Tracer", func() {
+		It("starts a span for the operation and propagates its request span to UAA", func() {
+			tracer := &fakeTracer{}
+			ctx := context.WithValue(context.Background(), "parent", "span")
+			auth := uaa.NewUAA("", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+			auth.Tracer = tracer
+
+			_, err := auth.UserByIDWithContext(ctx, "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tracer.spans).To(HaveLen(2))
+			operation := tracer.spans[0]
+			Expect(operation.operation).To(Equal("UserByID"))
+			Expect(operation.parent).To(Equal(ctx))
+			Expect(operation.ended).To(BeTrue())
+			Expect(operation.endedWith).To(Equal(http.StatusOK))
+
+			request := tracer.spans[1]
+			Expect(request.operation).To(Equal("HTTP GET"))
+			Expect(fakeSpanFromContext(request.parent)).To(BeIdenticalTo(operation))
+			Expect(request.attributes["http.method"]).To(Equal("GET"))
+			Expect(request.attributes["http.url"]).To(Equal(fakeUAAServer.URL + "/Users/87dfc5b4-daf9-49fd-9aa8-bb1e21d28929"))
+			Expect(request.ended).To(BeTrue())
+			Expect(request.endedWith).To(Equal(http.StatusOK))
+
+			Expect(traceParents).To(Equal([]string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba90202-01"}))
+		})
+
+		It("starts a single span for an operation making several requests", func() {
+			var requests int32
+			usersServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.Write([]byte(`{"resources": [], "totalResults": 0}`))
+			}))
+			defer usersServer.Close()
+
+			tracer := &fakeTracer{}
+			auth := uaa.NewUAA("", usersServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+			auth.Tracer = tracer
+
+			_, err := uaa.UsersByIDsWithMaxLength(auth, 150, "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", "e3c7d2ad-4c6b-4c7c-8c6a-fb1c2c3c0d3a", "6c8cda0e-3e2e-4b8a-9c1a-55c4f0b3a5d1")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(BeNumerically(">", 1))
+			Expect(tracer.spans).To(HaveLen(int(requests) + 1))
+			operation := tracer.spans[0]
+			Expect(operation.operation).To(Equal("UsersByIDs"))
+			Expect(operation.ended).To(BeTrue())
+			Expect(operation.endedWith).To(Equal(http.StatusOK))
+			for _, request := range tracer.spans[1:] {
+				Expect(request.operation).To(Equal("HTTP GET"))
+				Expect(fakeSpanFromContext(request.parent)).To(BeIdenticalTo(operation))
+				Expect(request.ended).To(BeTrue())
+			}
+		})
+
+		It("starts a span for requests made without an operation", func() {
+			tracer := &fakeTracer{}
+			client := uaa.NewClient(fakeUAAServer.URL, false).WithTracer(tracer)
+
+			client.MakeRequest("GET", "/token_key", nil)
+
+			Expect(tracer.spans).To(HaveLen(1))
+			Expect(tracer.spans[0].operation).To(Equal("Request"))
+			Expect(tracer.spans[0].ended).To(BeTrue())
+		})
+
+		It("leaves the query out of the span URL", func() {
+			tracer := &fakeTracer{}
+			client := uaa.NewClient(fakeUAAServer.URL, false).WithTracer(tracer)
+
+			client.MakeRequest("GET", `/Users?filter=email+eq+"jane@example.com"`, nil)
+
+			Expect(tracer.spans).To(HaveLen(1))
+			Expect(tracer.spans[0].attributes["http.url"]).To(Equal(fakeUAAServer.URL + "/Users"))
+		})
+	})
+
+	Context("without a Tracer", func() {
+		It("propagates the span context carried by the context", func() {
+			spanContext, err := uaa.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+			Expect(err).NotTo(HaveOccurred())
+
+			client := uaa.NewClient(fakeUAAServer.URL, false)
+			client.MakeRequestWithContext(uaa.ContextWithSpanContext(context.Background(), spanContext), "GET", "/token_key", nil)
+			client.MakeRequest("GET", "/token_key", nil)
+
+			Expect(traceParents).To(Equal([]string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", ""}))
+		})
+	})
+
+	Describe("ParseTraceParent", func() {
+		It("parses valid traceparent values", func() {
+			spanContext, err := uaa.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spanContext.TraceID).To(Equal([16]byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}))
+			Expect(spanContext.SpanID).To(Equal([8]byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7}))
+			Expect(spanContext.Sampled).To(BeTrue())
+			Expect(spanContext.TraceParent()).To(Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+		})
+
+		It("rejects invalid traceparent values", func() {
+			for _, value := range []string{
+				"",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+				"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+				"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+				"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+			} {
+				_, err := uaa.ParseTraceParent(value)
+				Expect(err).To(Equal(uaa.InvalidTraceParent), value)
+			}
+		})
+	})
+})
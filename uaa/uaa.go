@@ -8,25 +8,72 @@ Constructors are generally provided for objects a client needs to use
 package uaa
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 )
 
 var InvalidRefreshToken = errors.New("UAA Invalid Refresh Token")
 
+// Sentinels matched by Failure, use them with errors.Is or the Is* predicates
+var (
+	NotFoundError     = errors.New("UAA Not Found")
+	UnauthorizedError = errors.New("UAA Unauthorized")
+	ForbiddenError    = errors.New("UAA Forbidden")
+	ConflictError     = errors.New("UAA Conflict")
+	InvalidGrantError = errors.New("UAA Invalid Grant")
+	InvalidScopeError = errors.New("UAA Invalid Scope")
+)
+
 // used to encapuslate info about errors
 type Failure struct {
 	code    int
 	message string
+
+	errorType        string
+	errorDescription string
+	scimType         string
+	detail           string
+	status           string
 }
 
-// Failure constructor
+// UAA reports OAuth errors as {"error", "error_description"} and SCIM
+// errors as {"scimType", "detail", "status"}, where status may be a string
+// or a number
+type failureResponse struct {
+	Error            string          `json:"error"`
+	ErrorDescription string          `json:"error_description"`
+	SCIMType         string          `json:"scimType"`
+	Detail           string          `json:"detail"`
+	Status           json.RawMessage `json:"status"`
+}
+
+// Failure constructor, parses the OAuth or SCIM error in the body when present
 func NewFailure(code int, message []byte) Failure {
-	return Failure{
+	failure := Failure{
 		code:    code,
 		message: string(message),
 	}
+
+	var response failureResponse
+	if json.Unmarshal(message, &response) == nil {
+		failure.errorType = response.Error
+		failure.errorDescription = response.ErrorDescription
+		failure.scimType = response.SCIMType
+		failure.detail = response.Detail
+
+		var status string
+		if json.Unmarshal(response.Status, &status) == nil {
+			failure.status = status
+		} else if len(response.Status) > 0 && string(response.Status) != "null" {
+			failure.status = string(response.Status)
+		}
+	}
+
+	return failure
 }
 
 func (failure Failure) Code() int {
@@ -37,10 +84,78 @@ func (failure Failure) Message() string {
 	return failure.message
 }
 
+// OAuth error code, like "invalid_grant"
+func (failure Failure) ErrorType() string {
+	return failure.errorType
+}
+
+// Human readable OAuth error description
+func (failure Failure) ErrorDescription() string {
+	return failure.errorDescription
+}
+
+// SCIM error type, like "uniqueness" or "invalidVers"
+func (failure Failure) SCIMType() string {
+	return failure.scimType
+}
+
+// Human readable SCIM error detail
+func (failure Failure) Detail() string {
+	return failure.detail
+}
+
+// Status reported in the body of a SCIM error
+func (failure Failure) Status() string {
+	return failure.status
+}
+
 func (failure Failure) Error() string {
 	return fmt.Sprintf("UAA Failure: %d %s", failure.code, failure.message)
 }
 
+// Lets errors.Is match a Failure against the sentinel errors above
+func (failure Failure) Is(target error) bool {
+	switch target {
+	case NotFoundError:
+		return failure.code == http.StatusNotFound
+	case UnauthorizedError:
+		return failure.code == http.StatusUnauthorized
+	case ForbiddenError:
+		return failure.code == http.StatusForbidden
+	case ConflictError:
+		return failure.code == http.StatusConflict || failure.code == http.StatusPreconditionFailed
+	case InvalidGrantError:
+		return failure.errorType == "invalid_grant"
+	case InvalidScopeError:
+		return failure.errorType == "invalid_scope" || failure.scimType == "invalidScope"
+	}
+	return false
+}
+
+func IsNotFound(err error) bool {
+	return errors.Is(err, NotFoundError)
+}
+
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, UnauthorizedError)
+}
+
+func IsForbidden(err error) bool {
+	return errors.Is(err, ForbiddenError)
+}
+
+func IsConflict(err error) bool {
+	return errors.Is(err, ConflictError)
+}
+
+func IsInvalidGrant(err error) bool {
+	return errors.Is(err, InvalidGrantError)
+}
+
+func IsInvalidScope(err error) bool {
+	return errors.Is(err, InvalidScopeError)
+}
+
 // Defines methods needed for clients to use UAA
 type UAAInterface interface {
 	AuthorizeURLInterface
@@ -55,6 +170,19 @@ type UAAInterface interface {
 	UsersEmailsByIDsInterface
 	UsersGUIDsByScopeInterface
 	AllUsersInterface
+	IdentityZonesInterface
+	IdentityProvidersInterface
+	OAuthClientsInterface
+	ClientSecretRotationInterface
+	UserManagementInterface
+	SearchUsersInterface
+	LookupUsersByIDsInterface
+	UsersByUsernamesInterface
+	UsersByEmailsInterface
+	GroupsInterface
+	GroupMembersByScopeInterface
+	ExternalGroupMappingsInterface
+	ReconcileGroupsInterface
 }
 
 type AuthorizeURLInterface interface {
@@ -83,35 +211,157 @@ type UAA struct {
 	ApprovalPrompt string
 	AccessToken    string
 	VerifySSL      bool
+	RetryPolicy    RetryPolicy
+	Hooks          Hooks
+	CircuitBreaker *CircuitBreaker
+	Logger         Logger
+	Metrics        Metrics
+	Tracer         Tracer
+
+	// Sends the requests instead of the shared client returned by GetClient
+	HTTPClient *http.Client
+
+	// Secrets tried in order when UAA rejects ClientSecret while the client
+	// secret is being rotated, see ClientSecretRotationInterface
+	FallbackClientSecrets []string
 
-	ExchangeCommand          func(UAA, string) (Token, error)
-	RefreshCommand           func(UAA, string) (Token, error)
-	GetClientTokenCommand    func(UAA) (Token, error)
-	UserByIDCommand          func(UAA, string) (User, error)
-	GetTokenKeyCommand       func(UAA) (string, error)
-	UsersByIDsCommand        func(UAA, ...string) ([]User, error)
-	UsersEmailsByIDsCommand  func(UAA, ...string) ([]User, error)
-	UsersGUIDsByScopeCommand func(UAA, string) ([]string, error)
-	AllUsersCommand          func(UAA) ([]User, error)
+	// Identity zone targeted by SCIM and other admin calls, through the
+	// X-Identity-Zone-Id or X-Identity-Zone-Subdomain headers. Identity zones
+	// themselves are always managed from the calling zone.
+	IdentityZoneID        string
+	IdentityZoneSubdomain string
+
+	ctx       context.Context
+	operation string
+
+	ExchangeCommand                     func(UAA, string) (Token, error)
+	RefreshCommand                      func(UAA, string) (Token, error)
+	GetClientTokenCommand               func(UAA) (Token, error)
+	UserByIDCommand                     func(UAA, string) (User, error)
+	GetTokenKeyCommand                  func(UAA) (string, error)
+	UsersByIDsCommand                   func(UAA, ...string) ([]User, error)
+	UsersEmailsByIDsCommand             func(UAA, ...string) ([]User, error)
+	UsersGUIDsByScopeCommand            func(UAA, string) ([]string, error)
+	AllUsersCommand                     func(UAA) ([]User, error)
+	CreateIdentityZoneCommand           func(UAA, IdentityZone) (IdentityZone, error)
+	IdentityZoneByIDCommand             func(UAA, string) (IdentityZone, error)
+	IdentityZonesCommand                func(UAA) ([]IdentityZone, error)
+	UpdateIdentityZoneCommand           func(UAA, IdentityZone) (IdentityZone, error)
+	DeleteIdentityZoneCommand           func(UAA, string) error
+	CreateIdentityProviderCommand       func(UAA, IdentityProvider) (IdentityProvider, error)
+	IdentityProviderByIDCommand         func(UAA, string) (IdentityProvider, error)
+	IdentityProvidersCommand            func(UAA) ([]IdentityProvider, error)
+	UpdateIdentityProviderCommand       func(UAA, IdentityProvider) (IdentityProvider, error)
+	DeleteIdentityProviderCommand       func(UAA, string) error
+	UpdateIdentityProviderStatusCommand func(UAA, string, IdentityProviderStatus) (IdentityProviderStatus, error)
+	TestIdentityProviderCommand         func(UAA, IdentityProvider, string, string) error
+	CreateOAuthClientCommand            func(UAA, OAuthClient) (OAuthClient, error)
+	OAuthClientByIDCommand              func(UAA, string) (OAuthClient, error)
+	OAuthClientsCommand                 func(UAA, OAuthClientsQuery) (OAuthClientsPage, error)
+	UpdateOAuthClientCommand            func(UAA, OAuthClient) (OAuthClient, error)
+	DeleteOAuthClientCommand            func(UAA, string) error
+	ChangeOAuthClientSecretCommand      func(UAA, string, string, string) error
+	OAuthClientMetadataByIDCommand      func(UAA, string) (OAuthClientMetadata, error)
+	UpdateOAuthClientMetadataCommand    func(UAA, OAuthClientMetadata) (OAuthClientMetadata, error)
+	AddOAuthClientSecretCommand         func(UAA, string, string) error
+	DeleteOAuthClientSecretCommand      func(UAA, string) error
+	CreateUserCommand                   func(UAA, User) (User, error)
+	UpdateUserCommand                   func(UAA, User) (User, error)
+	PatchUserCommand                    func(UAA, string, int, map[string]interface{}) (User, error)
+	DeleteUserCommand                   func(UAA, string) error
+	SearchUsersCommand                  func(UAA, UsersQuery) (UsersPage, error)
+	LookupUsersByIDsCommand             func(UAA, ...string) (UsersLookup, error)
+	UsersByUsernamesCommand             func(UAA, ...string) ([]User, error)
+	UsersByUsernamesInOriginCommand     func(UAA, string, ...string) ([]User, error)
+	LookupUsersByUsernamesCommand       func(UAA, ...string) (UsersLookup, error)
+	UserByUsernameAndOriginCommand      func(UAA, string, string) (User, error)
+	UsersByEmailsCommand                func(UAA, ...string) ([]User, error)
+	LookupUsersByEmailsCommand          func(UAA, ...string) (UsersLookup, error)
+	CreateGroupCommand                  func(UAA, Group) (Group, error)
+	GroupByIDCommand                    func(UAA, string) (Group, error)
+	GroupsCommand                       func(UAA, GroupsQuery) (GroupsPage, error)
+	AllGroupsCommand                    func(UAA, Filter) ([]Group, error)
+	UpdateGroupCommand                  func(UAA, Group) (Group, error)
+	DeleteGroupCommand                  func(UAA, string) error
+	GroupMembersCommand                 func(UAA, string) ([]GroupMember, error)
+	AddGroupMemberCommand               func(UAA, string, GroupMember) (GroupMember, error)
+	RemoveGroupMemberCommand            func(UAA, string, string) error
+	GroupMembersByScopeCommand          func(UAA, string) ([]GroupMember, error)
+	ExternalGroupMappingsCommand        func(UAA, ExternalGroupMappingsQuery) (ExternalGroupMappingsPage, error)
+	AllExternalGroupMappingsCommand     func(UAA, ExternalGroupMappingsQuery) ([]ExternalGroupMapping, error)
+	CreateExternalGroupMappingCommand   func(UAA, ExternalGroupMapping) (ExternalGroupMapping, error)
+	DeleteExternalGroupMappingCommand   func(UAA, ExternalGroupMapping) error
+	PlanGroupsCommand                   func(UAA, []DesiredGroup) (GroupsPlan, error)
+	ApplyGroupsPlanCommand              func(UAA, GroupsPlan, ApplyGroupsOptions) (GroupsApplyReport, error)
 }
 
 func NewUAA(loginURL, uaaURL, clientID, clientSecret, token string) UAA {
 	return UAA{
-		loginURL:                 loginURL,
-		uaaURL:                   uaaURL,
-		ClientID:                 clientID,
-		ClientSecret:             clientSecret,
-		AccessToken:              token,
-		VerifySSL:                true,
-		ExchangeCommand:          Exchange,
-		GetClientTokenCommand:    GetClientToken,
-		GetTokenKeyCommand:       GetTokenKey,
-		RefreshCommand:           Refresh,
-		UserByIDCommand:          UserByID,
-		UsersByIDsCommand:        UsersByIDs,
-		UsersEmailsByIDsCommand:  UsersEmailsByIDs,
-		UsersGUIDsByScopeCommand: UsersGUIDsByScope,
-		AllUsersCommand:          AllUsers,
+		loginURL:                            loginURL,
+		uaaURL:                              uaaURL,
+		ClientID:                            clientID,
+		ClientSecret:                        clientSecret,
+		AccessToken:                         token,
+		VerifySSL:                           true,
+		ExchangeCommand:                     Exchange,
+		GetClientTokenCommand:               GetClientToken,
+		GetTokenKeyCommand:                  GetTokenKey,
+		RefreshCommand:                      Refresh,
+		UserByIDCommand:                     UserByID,
+		UsersByIDsCommand:                   UsersByIDs,
+		UsersEmailsByIDsCommand:             UsersEmailsByIDs,
+		UsersGUIDsByScopeCommand:            UsersGUIDsByScope,
+		AllUsersCommand:                     AllUsers,
+		CreateIdentityZoneCommand:           CreateIdentityZone,
+		IdentityZoneByIDCommand:             IdentityZoneByID,
+		IdentityZonesCommand:                IdentityZones,
+		UpdateIdentityZoneCommand:           UpdateIdentityZone,
+		DeleteIdentityZoneCommand:           DeleteIdentityZone,
+		CreateIdentityProviderCommand:       CreateIdentityProvider,
+		IdentityProviderByIDCommand:         IdentityProviderByID,
+		IdentityProvidersCommand:            IdentityProviders,
+		UpdateIdentityProviderCommand:       UpdateIdentityProvider,
+		DeleteIdentityProviderCommand:       DeleteIdentityProvider,
+		UpdateIdentityProviderStatusCommand: UpdateIdentityProviderStatus,
+		TestIdentityProviderCommand:         TestIdentityProvider,
+		CreateOAuthClientCommand:            CreateOAuthClient,
+		OAuthClientByIDCommand:              OAuthClientByID,
+		OAuthClientsCommand:                 OAuthClients,
+		UpdateOAuthClientCommand:            UpdateOAuthClient,
+		DeleteOAuthClientCommand:            DeleteOAuthClient,
+		ChangeOAuthClientSecretCommand:      ChangeOAuthClientSecret,
+		OAuthClientMetadataByIDCommand:      OAuthClientMetadataByID,
+		UpdateOAuthClientMetadataCommand:    UpdateOAuthClientMetadata,
+		AddOAuthClientSecretCommand:         AddOAuthClientSecret,
+		DeleteOAuthClientSecretCommand:      DeleteOAuthClientSecret,
+		CreateUserCommand:                   CreateUser,
+		UpdateUserCommand:                   UpdateUser,
+		PatchUserCommand:                    PatchUser,
+		DeleteUserCommand:                   DeleteUser,
+		SearchUsersCommand:                  SearchUsers,
+		LookupUsersByIDsCommand:             LookupUsersByIDs,
+		UsersByUsernamesCommand:             UsersByUsernames,
+		UsersByUsernamesInOriginCommand:     UsersByUsernamesInOrigin,
+		LookupUsersByUsernamesCommand:       LookupUsersByUsernames,
+		UserByUsernameAndOriginCommand:      UserByUsernameAndOrigin,
+		UsersByEmailsCommand:                UsersByEmails,
+		LookupUsersByEmailsCommand:          LookupUsersByEmails,
+		CreateGroupCommand:                  CreateGroup,
+		GroupByIDCommand:                    GroupByID,
+		GroupsCommand:                       Groups,
+		AllGroupsCommand:                    AllGroups,
+		UpdateGroupCommand:                  UpdateGroup,
+		DeleteGroupCommand:                  DeleteGroup,
+		GroupMembersCommand:                 GroupMembers,
+		AddGroupMemberCommand:               AddGroupMember,
+		RemoveGroupMemberCommand:            RemoveGroupMember,
+		GroupMembersByScopeCommand:          GroupMembersByScope,
+		ExternalGroupMappingsCommand:        ExternalGroupMappings,
+		AllExternalGroupMappingsCommand:     AllExternalGroupMappings,
+		CreateExternalGroupMappingCommand:   CreateExternalGroupMapping,
+		DeleteExternalGroupMappingCommand:   DeleteExternalGroupMapping,
+		PlanGroupsCommand:                   PlanGroups,
+		ApplyGroupsPlanCommand:              ApplyGroupsPlan,
 	}
 }
 
@@ -141,6 +391,119 @@ func (u UAA) tokenURL() string {
 	return fmt.Sprintf("%s/oauth/token", u.uaaURL)
 }
 
+// Returns a copy of the UAA whose requests are bound to the given context,
+// canceling the context aborts any in-flight or remaining requests
+func (u UAA) WithContext(ctx context.Context) UAA {
+	u.ctx = ctx
+	return u
+}
+
+// Returns the context requests are made with, defaulting to context.Background
+func (u UAA) Context() context.Context {
+	if u.ctx == nil {
+		return context.Background()
+	}
+	return u.ctx
+}
+
+// Names the operation requests are reported under in logs and metrics, and
+// starts its span when the UAA has a Tracer. The returned UAA carries the
+// span in its context, so the requests made for the operation are traced as
+// its children; the span must be ended once the operation is done.
+func (u UAA) withOperation(operation string) (UAA, *operationSpan) {
+	u.operation = operation
+	if u.Tracer == nil {
+		return u, nil
+	}
+
+	ctx, span := u.Tracer.StartSpan(u.Context(), operation)
+	opSpan := &operationSpan{span: span}
+	u.ctx = context.WithValue(ctx, operationSpanKey{}, opSpan)
+	return u, opSpan
+}
+
+// Builds a Client for the host of the given uri, carrying the UAA's settings
+func (u UAA) newClient(uri *url.URL) Client {
+	host := uri.Scheme + "://" + uri.Host
+	return NewClient(host, u.VerifySSL).
+		WithContext(u.Context()).
+		WithRetryPolicy(u.RetryPolicy).
+		WithHooks(u.Hooks).
+		WithCircuitBreaker(u.CircuitBreaker).
+		WithLogger(u.Logger).
+		WithMetrics(u.Metrics).
+		WithTracer(u.Tracer).
+		WithHTTPClient(u.HTTPClient).
+		WithOperation(u.operation)
+}
+
+// Builds a Client for SCIM and other admin endpoints, authorized with the
+// UAA's access token and switched to its identity zone
+func (u UAA) newAdminClient(uri *url.URL) Client {
+	return u.newClient(uri).
+		WithAuthorizationToken(u.AccessToken).
+		WithIdentityZone(u.IdentityZoneID, u.IdentityZoneSubdomain)
+}
+
+// Makes a JSON request to the given path of the UAA server with an admin
+// client, see Client.MakeJSONRequest
+func (u UAA) adminRequest(method, path string, header http.Header, requestBody, responseBody interface{}) error {
+	uri, err := url.Parse(u.uaaURL + path)
+	if err != nil {
+		return err
+	}
+
+	client := u.newAdminClient(uri)
+	for name := range header {
+		client = client.WithHeader(name, header.Get(name))
+	}
+
+	return client.MakeJSONRequest(method, uri.RequestURI(), requestBody, responseBody)
+}
+
+// Returns a copy of the UAA whose admin calls target the identity zone with
+// the given ID. The access token must carry zones.<id>.admin or similar scopes.
+func (u UAA) WithIdentityZoneID(zoneID string) UAA {
+	u.IdentityZoneID = zoneID
+	u.IdentityZoneSubdomain = ""
+	return u
+}
+
+// Returns a copy of the UAA whose admin calls target the identity zone with
+// the given subdomain
+func (u UAA) WithIdentityZoneSubdomain(subdomain string) UAA {
+	u.IdentityZoneID = ""
+	u.IdentityZoneSubdomain = subdomain
+	return u
+}
+
+// Returns a copy of the UAA talking to the identity zone with the given
+// subdomain directly, by prefixing the hosts of its login and UAA URLs,
+// for example http://uaa.example.com becomes http://tenant.uaa.example.com.
+// Token requests and logins then happen within that zone.
+func (u UAA) ForZoneSubdomain(subdomain string) UAA {
+	u.loginURL = zoneSubdomainURL(u.loginURL, subdomain)
+	u.uaaURL = zoneSubdomainURL(u.uaaURL, subdomain)
+	u.IdentityZoneID = ""
+	u.IdentityZoneSubdomain = ""
+	return u
+}
+
+// URLs that cannot be parsed are returned unchanged
+func zoneSubdomainURL(rawURL, subdomain string) string {
+	if subdomain == "" || rawURL == "" {
+		return rawURL
+	}
+
+	uri, err := url.Parse(rawURL)
+	if err != nil || uri.Host == "" {
+		return rawURL
+	}
+
+	uri.Host = subdomain + "." + uri.Host
+	return uri.String()
+}
+
 // Gets auth token based on the code UAA provides during redirect process
 func (u UAA) Exchange(authCode string) (Token, error) {
 	return u.ExchangeCommand(u, authCode)
@@ -180,3 +543,239 @@ func (u UAA) UsersGUIDsByScope(scope string) ([]string, error) {
 func (u UAA) AllUsers() ([]User, error) {
 	return u.AllUsersCommand(u)
 }
+
+func (u UAA) ExchangeWithContext(ctx context.Context, authCode string) (Token, error) {
+	return u.WithContext(ctx).Exchange(authCode)
+}
+
+func (u UAA) RefreshWithContext(ctx context.Context, refreshToken string) (Token, error) {
+	return u.WithContext(ctx).Refresh(refreshToken)
+}
+
+func (u UAA) GetClientTokenWithContext(ctx context.Context) (Token, error) {
+	return u.WithContext(ctx).GetClientToken()
+}
+
+func (u UAA) UserByIDWithContext(ctx context.Context, id string) (User, error) {
+	return u.WithContext(ctx).UserByID(id)
+}
+
+func (u UAA) GetTokenKeyWithContext(ctx context.Context) (string, error) {
+	return u.WithContext(ctx).GetTokenKey()
+}
+
+func (u UAA) UsersByIDsWithContext(ctx context.Context, ids ...string) ([]User, error) {
+	return u.WithContext(ctx).UsersByIDs(ids...)
+}
+
+func (u UAA) UsersEmailsByIDsWithContext(ctx context.Context, ids ...string) ([]User, error) {
+	return u.WithContext(ctx).UsersEmailsByIDs(ids...)
+}
+
+func (u UAA) UsersGUIDsByScopeWithContext(ctx context.Context, scope string) ([]string, error) {
+	return u.WithContext(ctx).UsersGUIDsByScope(scope)
+}
+
+func (u UAA) AllUsersWithContext(ctx context.Context) ([]User, error) {
+	return u.WithContext(ctx).AllUsers()
+}
+
+func (u UAA) CreateIdentityZone(zone IdentityZone) (IdentityZone, error) {
+	return u.CreateIdentityZoneCommand(u, zone)
+}
+
+func (u UAA) IdentityZoneByID(id string) (IdentityZone, error) {
+	return u.IdentityZoneByIDCommand(u, id)
+}
+
+func (u UAA) IdentityZones() ([]IdentityZone, error) {
+	return u.IdentityZonesCommand(u)
+}
+
+func (u UAA) UpdateIdentityZone(zone IdentityZone) (IdentityZone, error) {
+	return u.UpdateIdentityZoneCommand(u, zone)
+}
+
+func (u UAA) DeleteIdentityZone(id string) error {
+	return u.DeleteIdentityZoneCommand(u, id)
+}
+
+func (u UAA) CreateIdentityProvider(provider IdentityProvider) (IdentityProvider, error) {
+	return u.CreateIdentityProviderCommand(u, provider)
+}
+
+func (u UAA) IdentityProviderByID(id string) (IdentityProvider, error) {
+	return u.IdentityProviderByIDCommand(u, id)
+}
+
+func (u UAA) IdentityProviders() ([]IdentityProvider, error) {
+	return u.IdentityProvidersCommand(u)
+}
+
+func (u UAA) UpdateIdentityProvider(provider IdentityProvider) (IdentityProvider, error) {
+	return u.UpdateIdentityProviderCommand(u, provider)
+}
+
+func (u UAA) DeleteIdentityProvider(id string) error {
+	return u.DeleteIdentityProviderCommand(u, id)
+}
+
+func (u UAA) UpdateIdentityProviderStatus(id string, status IdentityProviderStatus) (IdentityProviderStatus, error) {
+	return u.UpdateIdentityProviderStatusCommand(u, id, status)
+}
+
+func (u UAA) TestIdentityProvider(provider IdentityProvider, username, password string) error {
+	return u.TestIdentityProviderCommand(u, provider, username, password)
+}
+
+func (u UAA) CreateOAuthClient(client OAuthClient) (OAuthClient, error) {
+	return u.CreateOAuthClientCommand(u, client)
+}
+
+func (u UAA) OAuthClientByID(id string) (OAuthClient, error) {
+	return u.OAuthClientByIDCommand(u, id)
+}
+
+func (u UAA) OAuthClients(query OAuthClientsQuery) (OAuthClientsPage, error) {
+	return u.OAuthClientsCommand(u, query)
+}
+
+func (u UAA) UpdateOAuthClient(client OAuthClient) (OAuthClient, error) {
+	return u.UpdateOAuthClientCommand(u, client)
+}
+
+func (u UAA) DeleteOAuthClient(id string) error {
+	return u.DeleteOAuthClientCommand(u, id)
+}
+
+func (u UAA) ChangeOAuthClientSecret(id, oldSecret, newSecret string) error {
+	return u.ChangeOAuthClientSecretCommand(u, id, oldSecret, newSecret)
+}
+
+func (u UAA) OAuthClientMetadataByID(id string) (OAuthClientMetadata, error) {
+	return u.OAuthClientMetadataByIDCommand(u, id)
+}
+
+func (u UAA) UpdateOAuthClientMetadata(metadata OAuthClientMetadata) (OAuthClientMetadata, error) {
+	return u.UpdateOAuthClientMetadataCommand(u, metadata)
+}
+
+func (u UAA) AddOAuthClientSecret(id, secret string) error {
+	return u.AddOAuthClientSecretCommand(u, id, secret)
+}
+
+func (u UAA) DeleteOAuthClientSecret(id string) error {
+	return u.DeleteOAuthClientSecretCommand(u, id)
+}
+
+func (u UAA) CreateUser(user User) (User, error) {
+	return u.CreateUserCommand(u, user)
+}
+
+func (u UAA) UpdateUser(user User) (User, error) {
+	return u.UpdateUserCommand(u, user)
+}
+
+func (u UAA) PatchUser(id string, version int, attributes map[string]interface{}) (User, error) {
+	return u.PatchUserCommand(u, id, version, attributes)
+}
+
+func (u UAA) DeleteUser(id string) error {
+	return u.DeleteUserCommand(u, id)
+}
+
+func (u UAA) SearchUsers(query UsersQuery) (UsersPage, error) {
+	return u.SearchUsersCommand(u, query)
+}
+
+func (u UAA) LookupUsersByIDs(ids ...string) (UsersLookup, error) {
+	return u.LookupUsersByIDsCommand(u, ids...)
+}
+
+func (u UAA) UsersByUsernames(usernames ...string) ([]User, error) {
+	return u.UsersByUsernamesCommand(u, usernames...)
+}
+
+func (u UAA) UsersByUsernamesInOrigin(origin string, usernames ...string) ([]User, error) {
+	return u.UsersByUsernamesInOriginCommand(u, origin, usernames...)
+}
+
+func (u UAA) LookupUsersByUsernames(usernames ...string) (UsersLookup, error) {
+	return u.LookupUsersByUsernamesCommand(u, usernames...)
+}
+
+func (u UAA) UserByUsernameAndOrigin(username, origin string) (User, error) {
+	return u.UserByUsernameAndOriginCommand(u, username, origin)
+}
+
+func (u UAA) UsersByEmails(emails ...string) ([]User, error) {
+	return u.UsersByEmailsCommand(u, emails...)
+}
+
+func (u UAA) LookupUsersByEmails(emails ...string) (UsersLookup, error) {
+	return u.LookupUsersByEmailsCommand(u, emails...)
+}
+
+func (u UAA) CreateGroup(group Group) (Group, error) {
+	return u.CreateGroupCommand(u, group)
+}
+
+func (u UAA) GroupByID(id string) (Group, error) {
+	return u.GroupByIDCommand(u, id)
+}
+
+func (u UAA) Groups(query GroupsQuery) (GroupsPage, error) {
+	return u.GroupsCommand(u, query)
+}
+
+func (u UAA) AllGroups(filter Filter) ([]Group, error) {
+	return u.AllGroupsCommand(u, filter)
+}
+
+func (u UAA) UpdateGroup(group Group) (Group, error) {
+	return u.UpdateGroupCommand(u, group)
+}
+
+func (u UAA) DeleteGroup(id string) error {
+	return u.DeleteGroupCommand(u, id)
+}
+
+func (u UAA) GroupMembers(groupID string) ([]GroupMember, error) {
+	return u.GroupMembersCommand(u, groupID)
+}
+
+func (u UAA) AddGroupMember(groupID string, member GroupMember) (GroupMember, error) {
+	return u.AddGroupMemberCommand(u, groupID, member)
+}
+
+func (u UAA) RemoveGroupMember(groupID, memberID string) error {
+	return u.RemoveGroupMemberCommand(u, groupID, memberID)
+}
+
+func (u UAA) GroupMembersByScope(scope string) ([]GroupMember, error) {
+	return u.GroupMembersByScopeCommand(u, scope)
+}
+
+func (u UAA) ExternalGroupMappings(query ExternalGroupMappingsQuery) (ExternalGroupMappingsPage, error) {
+	return u.ExternalGroupMappingsCommand(u, query)
+}
+
+func (u UAA) AllExternalGroupMappings(query ExternalGroupMappingsQuery) ([]ExternalGroupMapping, error) {
+	return u.AllExternalGroupMappingsCommand(u, query)
+}
+
+func (u UAA) CreateExternalGroupMapping(mapping ExternalGroupMapping) (ExternalGroupMapping, error) {
+	return u.CreateExternalGroupMappingCommand(u, mapping)
+}
+
+func (u UAA) DeleteExternalGroupMapping(mapping ExternalGroupMapping) error {
+	return u.DeleteExternalGroupMappingCommand(u, mapping)
+}
+
+func (u UAA) PlanGroups(desired []DesiredGroup) (GroupsPlan, error) {
+	return u.PlanGroupsCommand(u, desired)
+}
+
+func (u UAA) ApplyGroupsPlan(plan GroupsPlan, options ApplyGroupsOptions) (GroupsApplyReport, error) {
+	return u.ApplyGroupsPlanCommand(u, plan, options)
+}
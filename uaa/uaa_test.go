@@ -1,6 +1,12 @@
 package uaa_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 
 	"github.com/pivotal-cf/uaa-sso-golang/uaa"
@@ -22,6 +28,62 @@ var _ = Describe("UAA", func() {
 		})
 	})
 
+	Describe("Failure", func() {
+		It("parses OAuth errors", func() {
+			failure := uaa.NewFailure(400, []byte(`{"error":"invalid_grant","error_description":"Invalid authorization code: 1234"}`))
+
+			Expect(failure.Code()).To(Equal(400))
+			Expect(failure.ErrorType()).To(Equal("invalid_grant"))
+			Expect(failure.ErrorDescription()).To(Equal("Invalid authorization code: 1234"))
+			Expect(failure.Error()).To(Equal(`UAA Failure: 400 {"error":"invalid_grant","error_description":"Invalid authorization code: 1234"}`))
+		})
+
+		It("parses SCIM errors", func() {
+			failure := uaa.NewFailure(409, []byte(`{"scimType":"uniqueness","detail":"Username already in use: admin","status":"409"}`))
+
+			Expect(failure.SCIMType()).To(Equal("uniqueness"))
+			Expect(failure.Detail()).To(Equal("Username already in use: admin"))
+			Expect(failure.Status()).To(Equal("409"))
+		})
+
+		It("accepts numeric SCIM statuses", func() {
+			failure := uaa.NewFailure(404, []byte(`{"scimType":"invalidValue","status":404}`))
+			Expect(failure.Status()).To(Equal("404"))
+		})
+
+		It("keeps the raw message of bodies that are not JSON", func() {
+			failure := uaa.NewFailure(502, []byte(`<html>Bad Gateway</html>`))
+
+			Expect(failure.Message()).To(Equal(`<html>Bad Gateway</html>`))
+			Expect(failure.ErrorType()).To(Equal(""))
+			Expect(failure.SCIMType()).To(Equal(""))
+		})
+
+		It("matches the sentinel errors with errors.Is and the predicates", func() {
+			Expect(errors.Is(uaa.NewFailure(404, nil), uaa.NotFoundError)).To(BeTrue())
+			Expect(uaa.IsNotFound(uaa.NewFailure(404, nil))).To(BeTrue())
+			Expect(uaa.IsUnauthorized(uaa.NewFailure(401, nil))).To(BeTrue())
+			Expect(uaa.IsForbidden(uaa.NewFailure(403, nil))).To(BeTrue())
+			Expect(uaa.IsConflict(uaa.NewFailure(409, nil))).To(BeTrue())
+			Expect(uaa.IsConflict(uaa.NewFailure(412, nil))).To(BeTrue())
+			Expect(uaa.IsInvalidGrant(uaa.NewFailure(400, []byte(`{"error":"invalid_grant"}`)))).To(BeTrue())
+			Expect(uaa.IsInvalidScope(uaa.NewFailure(400, []byte(`{"error":"invalid_scope"}`)))).To(BeTrue())
+
+			Expect(uaa.IsNotFound(uaa.NewFailure(401, nil))).To(BeFalse())
+			Expect(uaa.IsInvalidGrant(uaa.NewFailure(400, []byte(`{"error":"invalid_scope"}`)))).To(BeFalse())
+			Expect(uaa.IsNotFound(errors.New("something else"))).To(BeFalse())
+		})
+
+		It("can be found in wrapped errors", func() {
+			err := fmt.Errorf("looking up user: %w", uaa.NewFailure(404, []byte(`{"error":"scim_resource_not_found"}`)))
+
+			var failure uaa.Failure
+			Expect(errors.As(err, &failure)).To(BeTrue())
+			Expect(failure.ErrorType()).To(Equal("scim_resource_not_found"))
+			Expect(uaa.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
 	Describe("AuthorizeURL", func() {
 		It("returns the URL for the /oauth/authorize endpoint", func() {
 			Expect(auth.AuthorizeURL()).To(Equal("http://login.example.com/oauth/authorize"))
@@ -41,6 +103,96 @@ var _ = Describe("UAA", func() {
 		})
 	})
 
+	Describe("identity zones", func() {
+		var fakeUAAServer *httptest.Server
+		var zoneHeaders map[string][]string
+
+		BeforeEach(func() {
+			zoneHeaders = map[string][]string{}
+			fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				zoneHeaders[req.URL.Path] = []string{req.Header.Get("X-Identity-Zone-Id"), req.Header.Get("X-Identity-Zone-Subdomain")}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"access_token": "client-access-token", "value": "THE-KEY", "resources": [], "totalResults": 0}`))
+			}))
+			auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+		})
+
+		AfterEach(func() {
+			fakeUAAServer.Close()
+		})
+
+		It("switches admin calls to the zone with the given ID", func() {
+			zoned := auth.WithIdentityZoneID("the-zone-id")
+
+			uaa.UserByID(zoned, "some-id")
+			uaa.AllUsers(zoned)
+			uaa.GetClientToken(zoned)
+
+			Expect(zoneHeaders["/Users/some-id"]).To(Equal([]string{"the-zone-id", ""}))
+			Expect(zoneHeaders["/Users"]).To(Equal([]string{"the-zone-id", ""}))
+			Expect(zoneHeaders["/oauth/token"]).To(Equal([]string{"", ""}))
+			Expect(auth.IdentityZoneID).To(Equal(""))
+		})
+
+		It("switches admin calls to the zone with the given subdomain", func() {
+			zoned := auth.WithIdentityZoneID("the-zone-id").WithIdentityZoneSubdomain("tenant")
+
+			uaa.UsersByIDs(zoned, "some-id")
+
+			Expect(zoneHeaders["/Users"]).To(Equal([]string{"", "tenant"}))
+		})
+
+		It("does not switch zones by default", func() {
+			uaa.UserByID(auth, "some-id")
+
+			Expect(zoneHeaders["/Users/some-id"]).To(Equal([]string{"", ""}))
+		})
+	})
+
+	Describe("ForZoneSubdomain", func() {
+		It("prefixes the login and UAA hosts with the zone subdomain", func() {
+			auth.ClientID = "fake-client"
+			zoned := auth.ForZoneSubdomain("tenant")
+
+			Expect(zoned.AuthorizeURL()).To(Equal("http://tenant.login.example.com/oauth/authorize"))
+			Expect(zoned.LoginURL()).To(HavePrefix("http://tenant.login.example.com/oauth/authorize?"))
+			Expect(auth.AuthorizeURL()).To(Equal("http://login.example.com/oauth/authorize"))
+		})
+
+		It("keeps ports and paths", func() {
+			auth = uaa.NewUAA("https://login.example.com:8443/uaa", "https://uaa.example.com", "the-client-id", "the-client-secret", "")
+
+			Expect(auth.ForZoneSubdomain("tenant").AuthorizeURL()).To(Equal("https://tenant.login.example.com:8443/uaa/oauth/authorize"))
+		})
+
+		It("sends token requests to the zone", func() {
+			var requestedHost string
+			fakeUAAServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requestedHost = req.Host
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"access_token": "client-access-token"}`))
+			}))
+			defer fakeUAAServer.Close()
+
+			auth = uaa.NewUAA("", fakeUAAServer.URL, "the-client-id", "the-client-secret", "")
+			// The zone's hostname does not resolve, so connect to the fake
+			// server whatever host is asked for
+			auth.HTTPClient = &http.Client{
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+						return (&net.Dialer{}).DialContext(ctx, network, fakeUAAServer.Listener.Addr().String())
+					},
+					DisableKeepAlives: true,
+				},
+			}
+			token, err := uaa.GetClientToken(auth.ForZoneSubdomain("tenant"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.Access).To(Equal("client-access-token"))
+			Expect(requestedHost).To(Equal("tenant." + fakeUAAServer.Listener.Addr().String()))
+		})
+	})
+
 	Describe("SetToken", func() {
 		It("assigns the given token value to the AccessToken field", func() {
 			Expect(auth.AccessToken).To(Equal(""))
@@ -51,6 +203,16 @@ var _ = Describe("UAA", func() {
 		})
 	})
 
+	Describe("WithContext", func() {
+		It("returns a copy of the UAA bound to the given context", func() {
+			ctx := context.WithValue(context.Background(), "key", "value")
+
+			Expect(auth.Context()).To(Equal(context.Background()))
+			Expect(auth.WithContext(ctx).Context()).To(Equal(ctx))
+			Expect(auth.Context()).To(Equal(context.Background()))
+		})
+	})
+
 	Describe("Exchange", func() {
 		var exchangeWasCalledWith string
 
@@ -68,6 +230,22 @@ var _ = Describe("UAA", func() {
 		})
 	})
 
+	Describe("ExchangeWithContext", func() {
+		It("delegates to the Exchange Command with the given context", func() {
+			var calledWithContext context.Context
+			ctx := context.WithValue(context.Background(), "key", "value")
+
+			auth.ExchangeCommand = func(u uaa.UAA, authCode string) (uaa.Token, error) {
+				calledWithContext = u.Context()
+				return uaa.Token{}, nil
+			}
+
+			auth.ExchangeWithContext(ctx, "auth-code")
+
+			Expect(calledWithContext).To(Equal(ctx))
+		})
+	})
+
 	Describe("Refresh", func() {
 		var refreshWasCalledWith string
 
@@ -184,4 +362,869 @@ var _ = Describe("UAA", func() {
 			Expect(allUsersWasCalled).To(BeTrue())
 		})
 	})
+
+	Describe("AllUsersWithContext", func() {
+		It("delegates to the AllUsers command with the given context", func() {
+			var calledWithContext context.Context
+			ctx := context.WithValue(context.Background(), "key", "value")
+
+			auth.AllUsersCommand = func(u uaa.UAA) ([]uaa.User, error) {
+				calledWithContext = u.Context()
+				return []uaa.User{}, nil
+			}
+
+			auth.AllUsersWithContext(ctx)
+			Expect(calledWithContext).To(Equal(ctx))
+		})
+	})
+
+	Describe("CreateIdentityZone", func() {
+		var createIdentityZoneWasCalledWith uaa.IdentityZone
+
+		It("delegates to the CreateIdentityZone command", func() {
+			Expect(reflect.ValueOf(auth.CreateIdentityZoneCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.CreateIdentityZone).Pointer()))
+
+			auth.CreateIdentityZoneCommand = func(u uaa.UAA, zone uaa.IdentityZone) (uaa.IdentityZone, error) {
+				createIdentityZoneWasCalledWith = zone
+				return zone, nil
+			}
+
+			auth.CreateIdentityZone(uaa.IdentityZone{Subdomain: "tenant"})
+
+			Expect(createIdentityZoneWasCalledWith.Subdomain).To(Equal("tenant"))
+		})
+	})
+
+	Describe("IdentityZoneByID", func() {
+		var identityZoneByIDWasCalledWith string
+
+		It("delegates to the IdentityZoneByID command", func() {
+			Expect(reflect.ValueOf(auth.IdentityZoneByIDCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.IdentityZoneByID).Pointer()))
+
+			auth.IdentityZoneByIDCommand = func(u uaa.UAA, id string) (uaa.IdentityZone, error) {
+				identityZoneByIDWasCalledWith = id
+				return uaa.IdentityZone{}, nil
+			}
+
+			auth.IdentityZoneByID("the-zone-id")
+
+			Expect(identityZoneByIDWasCalledWith).To(Equal("the-zone-id"))
+		})
+	})
+
+	Describe("IdentityZones", func() {
+		var identityZonesWasCalled bool
+
+		It("delegates to the IdentityZones command", func() {
+			Expect(reflect.ValueOf(auth.IdentityZonesCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.IdentityZones).Pointer()))
+
+			auth.IdentityZonesCommand = func(u uaa.UAA) ([]uaa.IdentityZone, error) {
+				identityZonesWasCalled = true
+				return []uaa.IdentityZone{}, nil
+			}
+
+			auth.IdentityZones()
+
+			Expect(identityZonesWasCalled).To(BeTrue())
+		})
+	})
+
+	Describe("UpdateIdentityZone", func() {
+		var updateIdentityZoneWasCalledWith uaa.IdentityZone
+
+		It("delegates to the UpdateIdentityZone command", func() {
+			Expect(reflect.ValueOf(auth.UpdateIdentityZoneCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.UpdateIdentityZone).Pointer()))
+
+			auth.UpdateIdentityZoneCommand = func(u uaa.UAA, zone uaa.IdentityZone) (uaa.IdentityZone, error) {
+				updateIdentityZoneWasCalledWith = zone
+				return zone, nil
+			}
+
+			auth.UpdateIdentityZone(uaa.IdentityZone{ID: "the-zone-id"})
+
+			Expect(updateIdentityZoneWasCalledWith.ID).To(Equal("the-zone-id"))
+		})
+	})
+
+	Describe("DeleteIdentityZone", func() {
+		var deleteIdentityZoneWasCalledWith string
+
+		It("delegates to the DeleteIdentityZone command", func() {
+			Expect(reflect.ValueOf(auth.DeleteIdentityZoneCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.DeleteIdentityZone).Pointer()))
+
+			auth.DeleteIdentityZoneCommand = func(u uaa.UAA, id string) error {
+				deleteIdentityZoneWasCalledWith = id
+				return nil
+			}
+
+			auth.DeleteIdentityZone("the-zone-id")
+
+			Expect(deleteIdentityZoneWasCalledWith).To(Equal("the-zone-id"))
+		})
+	})
+
+	Describe("CreateIdentityProvider", func() {
+		var createIdentityProviderWasCalledWith string
+
+		It("delegates to the CreateIdentityProvider command", func() {
+			Expect(reflect.ValueOf(auth.CreateIdentityProviderCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.CreateIdentityProvider).Pointer()))
+
+			auth.CreateIdentityProviderCommand = func(u uaa.UAA, provider uaa.IdentityProvider) (uaa.IdentityProvider, error) {
+				createIdentityProviderWasCalledWith = provider.OriginKey
+				return provider, nil
+			}
+
+			auth.CreateIdentityProvider(uaa.IdentityProvider{OriginKey: "ldap"})
+
+			Expect(createIdentityProviderWasCalledWith).To(Equal("ldap"))
+		})
+	})
+
+	Describe("IdentityProviderByID", func() {
+		var identityProviderByIDWasCalledWith string
+
+		It("delegates to the IdentityProviderByID command", func() {
+			Expect(reflect.ValueOf(auth.IdentityProviderByIDCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.IdentityProviderByID).Pointer()))
+
+			auth.IdentityProviderByIDCommand = func(u uaa.UAA, id string) (uaa.IdentityProvider, error) {
+				identityProviderByIDWasCalledWith = id
+				return uaa.IdentityProvider{}, nil
+			}
+
+			auth.IdentityProviderByID("the-provider-id")
+
+			Expect(identityProviderByIDWasCalledWith).To(Equal("the-provider-id"))
+		})
+	})
+
+	Describe("IdentityProviders", func() {
+		var identityProvidersWasCalled bool
+
+		It("delegates to the IdentityProviders command", func() {
+			Expect(reflect.ValueOf(auth.IdentityProvidersCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.IdentityProviders).Pointer()))
+
+			auth.IdentityProvidersCommand = func(u uaa.UAA) ([]uaa.IdentityProvider, error) {
+				identityProvidersWasCalled = true
+				return []uaa.IdentityProvider{}, nil
+			}
+
+			auth.IdentityProviders()
+
+			Expect(identityProvidersWasCalled).To(Equal(true))
+		})
+	})
+
+	Describe("UpdateIdentityProvider", func() {
+		var updateIdentityProviderWasCalledWith string
+
+		It("delegates to the UpdateIdentityProvider command", func() {
+			Expect(reflect.ValueOf(auth.UpdateIdentityProviderCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.UpdateIdentityProvider).Pointer()))
+
+			auth.UpdateIdentityProviderCommand = func(u uaa.UAA, provider uaa.IdentityProvider) (uaa.IdentityProvider, error) {
+				updateIdentityProviderWasCalledWith = provider.ID
+				return provider, nil
+			}
+
+			auth.UpdateIdentityProvider(uaa.IdentityProvider{ID: "the-provider-id"})
+
+			Expect(updateIdentityProviderWasCalledWith).To(Equal("the-provider-id"))
+		})
+	})
+
+	Describe("DeleteIdentityProvider", func() {
+		var deleteIdentityProviderWasCalledWith string
+
+		It("delegates to the DeleteIdentityProvider command", func() {
+			Expect(reflect.ValueOf(auth.DeleteIdentityProviderCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.DeleteIdentityProvider).Pointer()))
+
+			auth.DeleteIdentityProviderCommand = func(u uaa.UAA, id string) error {
+				deleteIdentityProviderWasCalledWith = id
+				return nil
+			}
+
+			auth.DeleteIdentityProvider("the-provider-id")
+
+			Expect(deleteIdentityProviderWasCalledWith).To(Equal("the-provider-id"))
+		})
+	})
+
+	Describe("UpdateIdentityProviderStatus", func() {
+		var updateIdentityProviderStatusWasCalledWith []interface{}
+
+		It("delegates to the UpdateIdentityProviderStatus command", func() {
+			Expect(reflect.ValueOf(auth.UpdateIdentityProviderStatusCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.UpdateIdentityProviderStatus).Pointer()))
+
+			auth.UpdateIdentityProviderStatusCommand = func(u uaa.UAA, id string, status uaa.IdentityProviderStatus) (uaa.IdentityProviderStatus, error) {
+				updateIdentityProviderStatusWasCalledWith = []interface{}{id, status.RequirePasswordChange}
+				return status, nil
+			}
+
+			auth.UpdateIdentityProviderStatus("the-provider-id", uaa.IdentityProviderStatus{RequirePasswordChange: true})
+
+			Expect(updateIdentityProviderStatusWasCalledWith).To(Equal([]interface{}{"the-provider-id", true}))
+		})
+	})
+
+	Describe("TestIdentityProvider", func() {
+		var testIdentityProviderWasCalledWith []string
+
+		It("delegates to the TestIdentityProvider command", func() {
+			Expect(reflect.ValueOf(auth.TestIdentityProviderCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.TestIdentityProvider).Pointer()))
+
+			auth.TestIdentityProviderCommand = func(u uaa.UAA, provider uaa.IdentityProvider, username, password string) error {
+				testIdentityProviderWasCalledWith = []string{provider.OriginKey, username, password}
+				return nil
+			}
+
+			auth.TestIdentityProvider(uaa.IdentityProvider{OriginKey: "ldap"}, "marissa", "koala")
+
+			Expect(testIdentityProviderWasCalledWith).To(Equal([]string{"ldap", "marissa", "koala"}))
+		})
+	})
+
+	Describe("CreateOAuthClient", func() {
+		var createOAuthClientWasCalledWith string
+
+		It("delegates to the CreateOAuthClient command", func() {
+			Expect(reflect.ValueOf(auth.CreateOAuthClientCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.CreateOAuthClient).Pointer()))
+
+			auth.CreateOAuthClientCommand = func(u uaa.UAA, client uaa.OAuthClient) (uaa.OAuthClient, error) {
+				createOAuthClientWasCalledWith = client.ClientID
+				return client, nil
+			}
+
+			auth.CreateOAuthClient(uaa.OAuthClient{ClientID: "my-app"})
+
+			Expect(createOAuthClientWasCalledWith).To(Equal("my-app"))
+		})
+	})
+
+	Describe("OAuthClientByID", func() {
+		var oAuthClientByIDWasCalledWith string
+
+		It("delegates to the OAuthClientByID command", func() {
+			Expect(reflect.ValueOf(auth.OAuthClientByIDCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.OAuthClientByID).Pointer()))
+
+			auth.OAuthClientByIDCommand = func(u uaa.UAA, id string) (uaa.OAuthClient, error) {
+				oAuthClientByIDWasCalledWith = id
+				return uaa.OAuthClient{}, nil
+			}
+
+			auth.OAuthClientByID("my-app")
+
+			Expect(oAuthClientByIDWasCalledWith).To(Equal("my-app"))
+		})
+	})
+
+	Describe("OAuthClients", func() {
+		var oAuthClientsWasCalledWith uaa.OAuthClientsQuery
+
+		It("delegates to the OAuthClients command", func() {
+			Expect(reflect.ValueOf(auth.OAuthClientsCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.OAuthClients).Pointer()))
+
+			auth.OAuthClientsCommand = func(u uaa.UAA, query uaa.OAuthClientsQuery) (uaa.OAuthClientsPage, error) {
+				oAuthClientsWasCalledWith = query
+				return uaa.OAuthClientsPage{}, nil
+			}
+
+			auth.OAuthClients(uaa.OAuthClientsQuery{Count: 10})
+
+			Expect(oAuthClientsWasCalledWith).To(Equal(uaa.OAuthClientsQuery{Count: 10}))
+		})
+	})
+
+	Describe("UpdateOAuthClient", func() {
+		var updateOAuthClientWasCalledWith string
+
+		It("delegates to the UpdateOAuthClient command", func() {
+			Expect(reflect.ValueOf(auth.UpdateOAuthClientCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.UpdateOAuthClient).Pointer()))
+
+			auth.UpdateOAuthClientCommand = func(u uaa.UAA, client uaa.OAuthClient) (uaa.OAuthClient, error) {
+				updateOAuthClientWasCalledWith = client.ClientID
+				return client, nil
+			}
+
+			auth.UpdateOAuthClient(uaa.OAuthClient{ClientID: "my-app"})
+
+			Expect(updateOAuthClientWasCalledWith).To(Equal("my-app"))
+		})
+	})
+
+	Describe("DeleteOAuthClient", func() {
+		var deleteOAuthClientWasCalledWith string
+
+		It("delegates to the DeleteOAuthClient command", func() {
+			Expect(reflect.ValueOf(auth.DeleteOAuthClientCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.DeleteOAuthClient).Pointer()))
+
+			auth.DeleteOAuthClientCommand = func(u uaa.UAA, id string) error {
+				deleteOAuthClientWasCalledWith = id
+				return nil
+			}
+
+			auth.DeleteOAuthClient("my-app")
+
+			Expect(deleteOAuthClientWasCalledWith).To(Equal("my-app"))
+		})
+	})
+
+	Describe("ChangeOAuthClientSecret", func() {
+		var changeOAuthClientSecretWasCalledWith []string
+
+		It("delegates to the ChangeOAuthClientSecret command", func() {
+			Expect(reflect.ValueOf(auth.ChangeOAuthClientSecretCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.ChangeOAuthClientSecret).Pointer()))
+
+			auth.ChangeOAuthClientSecretCommand = func(u uaa.UAA, id, oldSecret, newSecret string) error {
+				changeOAuthClientSecretWasCalledWith = []string{id, oldSecret, newSecret}
+				return nil
+			}
+
+			auth.ChangeOAuthClientSecret("my-app", "old", "new")
+
+			Expect(changeOAuthClientSecretWasCalledWith).To(Equal([]string{"my-app", "old", "new"}))
+		})
+	})
+
+	Describe("OAuthClientMetadataByID", func() {
+		var oAuthClientMetadataByIDWasCalledWith string
+
+		It("delegates to the OAuthClientMetadataByID command", func() {
+			Expect(reflect.ValueOf(auth.OAuthClientMetadataByIDCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.OAuthClientMetadataByID).Pointer()))
+
+			auth.OAuthClientMetadataByIDCommand = func(u uaa.UAA, id string) (uaa.OAuthClientMetadata, error) {
+				oAuthClientMetadataByIDWasCalledWith = id
+				return uaa.OAuthClientMetadata{}, nil
+			}
+
+			auth.OAuthClientMetadataByID("my-app")
+
+			Expect(oAuthClientMetadataByIDWasCalledWith).To(Equal("my-app"))
+		})
+	})
+
+	Describe("UpdateOAuthClientMetadata", func() {
+		var updateOAuthClientMetadataWasCalledWith string
+
+		It("delegates to the UpdateOAuthClientMetadata command", func() {
+			Expect(reflect.ValueOf(auth.UpdateOAuthClientMetadataCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.UpdateOAuthClientMetadata).Pointer()))
+
+			auth.UpdateOAuthClientMetadataCommand = func(u uaa.UAA, metadata uaa.OAuthClientMetadata) (uaa.OAuthClientMetadata, error) {
+				updateOAuthClientMetadataWasCalledWith = metadata.ClientID
+				return metadata, nil
+			}
+
+			auth.UpdateOAuthClientMetadata(uaa.OAuthClientMetadata{ClientID: "my-app"})
+
+			Expect(updateOAuthClientMetadataWasCalledWith).To(Equal("my-app"))
+		})
+	})
+
+	Describe("AddOAuthClientSecret", func() {
+		var addOAuthClientSecretWasCalledWith []string
+
+		It("delegates to the AddOAuthClientSecret command", func() {
+			Expect(reflect.ValueOf(auth.AddOAuthClientSecretCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.AddOAuthClientSecret).Pointer()))
+
+			auth.AddOAuthClientSecretCommand = func(u uaa.UAA, id, secret string) error {
+				addOAuthClientSecretWasCalledWith = []string{id, secret}
+				return nil
+			}
+
+			auth.AddOAuthClientSecret("my-app", "new-secret")
+
+			Expect(addOAuthClientSecretWasCalledWith).To(Equal([]string{"my-app", "new-secret"}))
+		})
+	})
+
+	Describe("DeleteOAuthClientSecret", func() {
+		var deleteOAuthClientSecretWasCalledWith string
+
+		It("delegates to the DeleteOAuthClientSecret command", func() {
+			Expect(reflect.ValueOf(auth.DeleteOAuthClientSecretCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.DeleteOAuthClientSecret).Pointer()))
+
+			auth.DeleteOAuthClientSecretCommand = func(u uaa.UAA, id string) error {
+				deleteOAuthClientSecretWasCalledWith = id
+				return nil
+			}
+
+			auth.DeleteOAuthClientSecret("my-app")
+
+			Expect(deleteOAuthClientSecretWasCalledWith).To(Equal("my-app"))
+		})
+	})
+
+	Describe("CreateUser", func() {
+		var createUserWasCalledWith string
+
+		It("delegates to the CreateUser command", func() {
+			Expect(reflect.ValueOf(auth.CreateUserCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.CreateUser).Pointer()))
+
+			auth.CreateUserCommand = func(u uaa.UAA, user uaa.User) (uaa.User, error) {
+				createUserWasCalledWith = user.Username
+				return user, nil
+			}
+
+			auth.CreateUser(uaa.User{Username: "jane"})
+
+			Expect(createUserWasCalledWith).To(Equal("jane"))
+		})
+	})
+
+	Describe("UpdateUser", func() {
+		var updateUserWasCalledWith string
+
+		It("delegates to the UpdateUser command", func() {
+			Expect(reflect.ValueOf(auth.UpdateUserCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.UpdateUser).Pointer()))
+
+			auth.UpdateUserCommand = func(u uaa.UAA, user uaa.User) (uaa.User, error) {
+				updateUserWasCalledWith = user.ID
+				return user, nil
+			}
+
+			auth.UpdateUser(uaa.User{ID: "the-user-id"})
+
+			Expect(updateUserWasCalledWith).To(Equal("the-user-id"))
+		})
+	})
+
+	Describe("PatchUser", func() {
+		var patchUserWasCalledWith []interface{}
+
+		It("delegates to the PatchUser command", func() {
+			Expect(reflect.ValueOf(auth.PatchUserCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.PatchUser).Pointer()))
+
+			auth.PatchUserCommand = func(u uaa.UAA, id string, version int, attributes map[string]interface{}) (uaa.User, error) {
+				patchUserWasCalledWith = []interface{}{id, version, attributes}
+				return uaa.User{}, nil
+			}
+
+			auth.PatchUser("the-user-id", 3, map[string]interface{}{"active": false})
+
+			Expect(patchUserWasCalledWith).To(Equal([]interface{}{"the-user-id", 3, map[string]interface{}{"active": false}}))
+		})
+	})
+
+	Describe("DeleteUser", func() {
+		var deleteUserWasCalledWith string
+
+		It("delegates to the DeleteUser command", func() {
+			Expect(reflect.ValueOf(auth.DeleteUserCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.DeleteUser).Pointer()))
+
+			auth.DeleteUserCommand = func(u uaa.UAA, id string) error {
+				deleteUserWasCalledWith = id
+				return nil
+			}
+
+			auth.DeleteUser("the-user-id")
+
+			Expect(deleteUserWasCalledWith).To(Equal("the-user-id"))
+		})
+	})
+
+	Describe("SearchUsers", func() {
+		var searchUsersWasCalledWith string
+
+		It("delegates to the SearchUsers command", func() {
+			Expect(reflect.ValueOf(auth.SearchUsersCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.SearchUsers).Pointer()))
+
+			auth.SearchUsersCommand = func(u uaa.UAA, query uaa.UsersQuery) (uaa.UsersPage, error) {
+				searchUsersWasCalledWith = query.SortBy
+				return uaa.UsersPage{}, nil
+			}
+
+			auth.SearchUsers(uaa.UsersQuery{SortBy: "userName"})
+
+			Expect(searchUsersWasCalledWith).To(Equal("userName"))
+		})
+	})
+
+	Describe("LookupUsersByIDs", func() {
+		var lookupUsersByIDsWasCalledWith []string
+
+		It("delegates to the LookupUsersByIDs command", func() {
+			Expect(reflect.ValueOf(auth.LookupUsersByIDsCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.LookupUsersByIDs).Pointer()))
+
+			auth.LookupUsersByIDsCommand = func(u uaa.UAA, ids ...string) (uaa.UsersLookup, error) {
+				lookupUsersByIDsWasCalledWith = ids
+				return uaa.UsersLookup{}, nil
+			}
+
+			auth.LookupUsersByIDs("first-id", "second-id")
+
+			Expect(lookupUsersByIDsWasCalledWith).To(Equal([]string{"first-id", "second-id"}))
+		})
+	})
+
+	Describe("UsersByUsernames", func() {
+		var usersByUsernamesWasCalledWith []string
+
+		It("delegates to the UsersByUsernames command", func() {
+			Expect(reflect.ValueOf(auth.UsersByUsernamesCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.UsersByUsernames).Pointer()))
+
+			auth.UsersByUsernamesCommand = func(u uaa.UAA, usernames ...string) ([]uaa.User, error) {
+				usersByUsernamesWasCalledWith = usernames
+				return []uaa.User{}, nil
+			}
+
+			auth.UsersByUsernames("admin", "other")
+
+			Expect(usersByUsernamesWasCalledWith).To(Equal([]string{"admin", "other"}))
+		})
+	})
+
+	Describe("UsersByUsernamesInOrigin", func() {
+		var usersByUsernamesInOriginWasCalledWith []string
+
+		It("delegates to the UsersByUsernamesInOrigin command", func() {
+			Expect(reflect.ValueOf(auth.UsersByUsernamesInOriginCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.UsersByUsernamesInOrigin).Pointer()))
+
+			auth.UsersByUsernamesInOriginCommand = func(u uaa.UAA, origin string, usernames ...string) ([]uaa.User, error) {
+				usersByUsernamesInOriginWasCalledWith = append([]string{origin}, usernames...)
+				return []uaa.User{}, nil
+			}
+
+			auth.UsersByUsernamesInOrigin("ldap", "admin")
+
+			Expect(usersByUsernamesInOriginWasCalledWith).To(Equal([]string{"ldap", "admin"}))
+		})
+	})
+
+	Describe("LookupUsersByUsernames", func() {
+		var lookupUsersByUsernamesWasCalledWith []string
+
+		It("delegates to the LookupUsersByUsernames command", func() {
+			Expect(reflect.ValueOf(auth.LookupUsersByUsernamesCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.LookupUsersByUsernames).Pointer()))
+
+			auth.LookupUsersByUsernamesCommand = func(u uaa.UAA, usernames ...string) (uaa.UsersLookup, error) {
+				lookupUsersByUsernamesWasCalledWith = usernames
+				return uaa.UsersLookup{}, nil
+			}
+
+			auth.LookupUsersByUsernames("admin")
+
+			Expect(lookupUsersByUsernamesWasCalledWith).To(Equal([]string{"admin"}))
+		})
+	})
+
+	Describe("UserByUsernameAndOrigin", func() {
+		var userByUsernameAndOriginWasCalledWith []string
+
+		It("delegates to the UserByUsernameAndOrigin command", func() {
+			Expect(reflect.ValueOf(auth.UserByUsernameAndOriginCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.UserByUsernameAndOrigin).Pointer()))
+
+			auth.UserByUsernameAndOriginCommand = func(u uaa.UAA, username, origin string) (uaa.User, error) {
+				userByUsernameAndOriginWasCalledWith = []string{username, origin}
+				return uaa.User{}, nil
+			}
+
+			auth.UserByUsernameAndOrigin("admin", "ldap")
+
+			Expect(userByUsernameAndOriginWasCalledWith).To(Equal([]string{"admin", "ldap"}))
+		})
+	})
+
+	Describe("UsersByEmails", func() {
+		var usersByEmailsWasCalledWith []string
+
+		It("delegates to the UsersByEmails command", func() {
+			Expect(reflect.ValueOf(auth.UsersByEmailsCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.UsersByEmails).Pointer()))
+
+			auth.UsersByEmailsCommand = func(u uaa.UAA, emails ...string) ([]uaa.User, error) {
+				usersByEmailsWasCalledWith = emails
+				return []uaa.User{}, nil
+			}
+
+			auth.UsersByEmails("admin@corp.com")
+
+			Expect(usersByEmailsWasCalledWith).To(Equal([]string{"admin@corp.com"}))
+		})
+	})
+
+	Describe("LookupUsersByEmails", func() {
+		var lookupUsersByEmailsWasCalledWith []string
+
+		It("delegates to the LookupUsersByEmails command", func() {
+			Expect(reflect.ValueOf(auth.LookupUsersByEmailsCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.LookupUsersByEmails).Pointer()))
+
+			auth.LookupUsersByEmailsCommand = func(u uaa.UAA, emails ...string) (uaa.UsersLookup, error) {
+				lookupUsersByEmailsWasCalledWith = emails
+				return uaa.UsersLookup{}, nil
+			}
+
+			auth.LookupUsersByEmails("admin@corp.com")
+
+			Expect(lookupUsersByEmailsWasCalledWith).To(Equal([]string{"admin@corp.com"}))
+		})
+	})
+
+	Describe("CreateGroup", func() {
+		var createGroupWasCalledWith uaa.Group
+
+		It("delegates to the CreateGroup command", func() {
+			Expect(reflect.ValueOf(auth.CreateGroupCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.CreateGroup).Pointer()))
+
+			auth.CreateGroupCommand = func(u uaa.UAA, group uaa.Group) (uaa.Group, error) {
+				createGroupWasCalledWith = group
+				return group, nil
+			}
+
+			auth.CreateGroup(uaa.Group{DisplayName: "scope"})
+
+			Expect(createGroupWasCalledWith).To(Equal(uaa.Group{DisplayName: "scope"}))
+		})
+	})
+
+	Describe("GroupByID", func() {
+		var groupByIDWasCalledWith string
+
+		It("delegates to the GroupByID command", func() {
+			Expect(reflect.ValueOf(auth.GroupByIDCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.GroupByID).Pointer()))
+
+			auth.GroupByIDCommand = func(u uaa.UAA, id string) (uaa.Group, error) {
+				groupByIDWasCalledWith = id
+				return uaa.Group{}, nil
+			}
+
+			auth.GroupByID("group-id")
+
+			Expect(groupByIDWasCalledWith).To(Equal("group-id"))
+		})
+	})
+
+	Describe("Groups", func() {
+		var groupsWasCalledWith uaa.GroupsQuery
+
+		It("delegates to the Groups command", func() {
+			Expect(reflect.ValueOf(auth.GroupsCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.Groups).Pointer()))
+
+			auth.GroupsCommand = func(u uaa.UAA, query uaa.GroupsQuery) (uaa.GroupsPage, error) {
+				groupsWasCalledWith = query
+				return uaa.GroupsPage{}, nil
+			}
+
+			auth.Groups(uaa.GroupsQuery{Count: 5})
+
+			Expect(groupsWasCalledWith).To(Equal(uaa.GroupsQuery{Count: 5}))
+		})
+	})
+
+	Describe("AllGroups", func() {
+		var allGroupsWasCalledWith uaa.Filter
+
+		It("delegates to the AllGroups command", func() {
+			Expect(reflect.ValueOf(auth.AllGroupsCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.AllGroups).Pointer()))
+
+			auth.AllGroupsCommand = func(u uaa.UAA, filter uaa.Filter) ([]uaa.Group, error) {
+				allGroupsWasCalledWith = filter
+				return []uaa.Group{}, nil
+			}
+
+			auth.AllGroups(uaa.Pr("description"))
+
+			Expect(allGroupsWasCalledWith).To(Equal(uaa.Pr("description")))
+		})
+	})
+
+	Describe("UpdateGroup", func() {
+		var updateGroupWasCalledWith uaa.Group
+
+		It("delegates to the UpdateGroup command", func() {
+			Expect(reflect.ValueOf(auth.UpdateGroupCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.UpdateGroup).Pointer()))
+
+			auth.UpdateGroupCommand = func(u uaa.UAA, group uaa.Group) (uaa.Group, error) {
+				updateGroupWasCalledWith = group
+				return group, nil
+			}
+
+			auth.UpdateGroup(uaa.Group{ID: "group-id"})
+
+			Expect(updateGroupWasCalledWith).To(Equal(uaa.Group{ID: "group-id"}))
+		})
+	})
+
+	Describe("DeleteGroup", func() {
+		var deleteGroupWasCalledWith string
+
+		It("delegates to the DeleteGroup command", func() {
+			Expect(reflect.ValueOf(auth.DeleteGroupCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.DeleteGroup).Pointer()))
+
+			auth.DeleteGroupCommand = func(u uaa.UAA, id string) error {
+				deleteGroupWasCalledWith = id
+				return nil
+			}
+
+			auth.DeleteGroup("group-id")
+
+			Expect(deleteGroupWasCalledWith).To(Equal("group-id"))
+		})
+	})
+
+	Describe("GroupMembers", func() {
+		var groupMembersWasCalledWith string
+
+		It("delegates to the GroupMembers command", func() {
+			Expect(reflect.ValueOf(auth.GroupMembersCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.GroupMembers).Pointer()))
+
+			auth.GroupMembersCommand = func(u uaa.UAA, groupID string) ([]uaa.GroupMember, error) {
+				groupMembersWasCalledWith = groupID
+				return []uaa.GroupMember{}, nil
+			}
+
+			auth.GroupMembers("group-id")
+
+			Expect(groupMembersWasCalledWith).To(Equal("group-id"))
+		})
+	})
+
+	Describe("AddGroupMember", func() {
+		var addGroupMemberWasCalledWith []interface{}
+
+		It("delegates to the AddGroupMember command", func() {
+			Expect(reflect.ValueOf(auth.AddGroupMemberCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.AddGroupMember).Pointer()))
+
+			auth.AddGroupMemberCommand = func(u uaa.UAA, groupID string, member uaa.GroupMember) (uaa.GroupMember, error) {
+				addGroupMemberWasCalledWith = []interface{}{groupID, member}
+				return member, nil
+			}
+
+			auth.AddGroupMember("group-id", uaa.GroupMember{Value: "user-id"})
+
+			Expect(addGroupMemberWasCalledWith).To(Equal([]interface{}{"group-id", uaa.GroupMember{Value: "user-id"}}))
+		})
+	})
+
+	Describe("RemoveGroupMember", func() {
+		var removeGroupMemberWasCalledWith []string
+
+		It("delegates to the RemoveGroupMember command", func() {
+			Expect(reflect.ValueOf(auth.RemoveGroupMemberCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.RemoveGroupMember).Pointer()))
+
+			auth.RemoveGroupMemberCommand = func(u uaa.UAA, groupID, memberID string) error {
+				removeGroupMemberWasCalledWith = []string{groupID, memberID}
+				return nil
+			}
+
+			auth.RemoveGroupMember("group-id", "user-id")
+
+			Expect(removeGroupMemberWasCalledWith).To(Equal([]string{"group-id", "user-id"}))
+		})
+	})
+
+	Describe("GroupMembersByScope", func() {
+		var groupMembersByScopeWasCalledWith string
+
+		It("delegates to the GroupMembersByScope command", func() {
+			Expect(reflect.ValueOf(auth.GroupMembersByScopeCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.GroupMembersByScope).Pointer()))
+
+			auth.GroupMembersByScopeCommand = func(u uaa.UAA, scope string) ([]uaa.GroupMember, error) {
+				groupMembersByScopeWasCalledWith = scope
+				return []uaa.GroupMember{}, nil
+			}
+
+			auth.GroupMembersByScope("this.scope")
+
+			Expect(groupMembersByScopeWasCalledWith).To(Equal("this.scope"))
+		})
+	})
+
+	Describe("ExternalGroupMappings", func() {
+		var externalGroupMappingsWasCalledWith uaa.ExternalGroupMappingsQuery
+
+		It("delegates to the ExternalGroupMappings command", func() {
+			Expect(reflect.ValueOf(auth.ExternalGroupMappingsCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.ExternalGroupMappings).Pointer()))
+
+			auth.ExternalGroupMappingsCommand = func(u uaa.UAA, query uaa.ExternalGroupMappingsQuery) (uaa.ExternalGroupMappingsPage, error) {
+				externalGroupMappingsWasCalledWith = query
+				return uaa.ExternalGroupMappingsPage{}, nil
+			}
+
+			auth.ExternalGroupMappings(uaa.ExternalGroupMappingsQuery{Origin: "ldap"})
+
+			Expect(externalGroupMappingsWasCalledWith).To(Equal(uaa.ExternalGroupMappingsQuery{Origin: "ldap"}))
+		})
+	})
+
+	Describe("AllExternalGroupMappings", func() {
+		var allExternalGroupMappingsWasCalledWith uaa.ExternalGroupMappingsQuery
+
+		It("delegates to the AllExternalGroupMappings command", func() {
+			Expect(reflect.ValueOf(auth.AllExternalGroupMappingsCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.AllExternalGroupMappings).Pointer()))
+
+			auth.AllExternalGroupMappingsCommand = func(u uaa.UAA, query uaa.ExternalGroupMappingsQuery) ([]uaa.ExternalGroupMapping, error) {
+				allExternalGroupMappingsWasCalledWith = query
+				return []uaa.ExternalGroupMapping{}, nil
+			}
+
+			auth.AllExternalGroupMappings(uaa.ExternalGroupMappingsQuery{Origin: "saml"})
+
+			Expect(allExternalGroupMappingsWasCalledWith).To(Equal(uaa.ExternalGroupMappingsQuery{Origin: "saml"}))
+		})
+	})
+
+	Describe("CreateExternalGroupMapping", func() {
+		var createExternalGroupMappingWasCalledWith uaa.ExternalGroupMapping
+
+		It("delegates to the CreateExternalGroupMapping command", func() {
+			Expect(reflect.ValueOf(auth.CreateExternalGroupMappingCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.CreateExternalGroupMapping).Pointer()))
+
+			auth.CreateExternalGroupMappingCommand = func(u uaa.UAA, mapping uaa.ExternalGroupMapping) (uaa.ExternalGroupMapping, error) {
+				createExternalGroupMappingWasCalledWith = mapping
+				return mapping, nil
+			}
+
+			auth.CreateExternalGroupMapping(uaa.ExternalGroupMapping{ExternalGroup: "admins"})
+
+			Expect(createExternalGroupMappingWasCalledWith).To(Equal(uaa.ExternalGroupMapping{ExternalGroup: "admins"}))
+		})
+	})
+
+	Describe("DeleteExternalGroupMapping", func() {
+		var deleteExternalGroupMappingWasCalledWith uaa.ExternalGroupMapping
+
+		It("delegates to the DeleteExternalGroupMapping command", func() {
+			Expect(reflect.ValueOf(auth.DeleteExternalGroupMappingCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.DeleteExternalGroupMapping).Pointer()))
+
+			auth.DeleteExternalGroupMappingCommand = func(u uaa.UAA, mapping uaa.ExternalGroupMapping) error {
+				deleteExternalGroupMappingWasCalledWith = mapping
+				return nil
+			}
+
+			auth.DeleteExternalGroupMapping(uaa.ExternalGroupMapping{ExternalGroup: "admins"})
+
+			Expect(deleteExternalGroupMappingWasCalledWith).To(Equal(uaa.ExternalGroupMapping{ExternalGroup: "admins"}))
+		})
+	})
+
+	Describe("PlanGroups", func() {
+		var planGroupsWasCalledWith []uaa.DesiredGroup
+
+		It("delegates to the PlanGroups command", func() {
+			Expect(reflect.ValueOf(auth.PlanGroupsCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.PlanGroups).Pointer()))
+
+			auth.PlanGroupsCommand = func(u uaa.UAA, desired []uaa.DesiredGroup) (uaa.GroupsPlan, error) {
+				planGroupsWasCalledWith = desired
+				return uaa.GroupsPlan{}, nil
+			}
+
+			auth.PlanGroups([]uaa.DesiredGroup{{DisplayName: "scope"}})
+
+			Expect(planGroupsWasCalledWith).To(Equal([]uaa.DesiredGroup{{DisplayName: "scope"}}))
+		})
+	})
+
+	Describe("ApplyGroupsPlan", func() {
+		var applyGroupsPlanWasCalledWith uaa.ApplyGroupsOptions
+
+		It("delegates to the ApplyGroupsPlan command", func() {
+			Expect(reflect.ValueOf(auth.ApplyGroupsPlanCommand).Pointer()).To(Equal(reflect.ValueOf(uaa.ApplyGroupsPlan).Pointer()))
+
+			auth.ApplyGroupsPlanCommand = func(u uaa.UAA, plan uaa.GroupsPlan, options uaa.ApplyGroupsOptions) (uaa.GroupsApplyReport, error) {
+				applyGroupsPlanWasCalledWith = options
+				return uaa.GroupsApplyReport{}, nil
+			}
+
+			auth.ApplyGroupsPlan(uaa.GroupsPlan{}, uaa.ApplyGroupsOptions{DryRun: true})
+
+			Expect(applyGroupsPlanWasCalledWith).To(Equal(uaa.ApplyGroupsOptions{DryRun: true}))
+		})
+	})
 })
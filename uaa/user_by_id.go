@@ -4,19 +4,36 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"time"
 )
 
 type UserByIDInterface interface {
 	UserByID(string) (User, error)
 }
 
+// SCIM user. Groups and Approvals are maintained by UAA and ignored when
+// writing users. Times UAA does not report are left zero.
 type User struct {
-	Username string
-	ID       string
-	Name     Name
-	Emails   []string
-	Active   bool
-	Verified bool
+	Username             string
+	ID                   string
+	ExternalID           string
+	Name                 Name
+	Emails               []string
+	PrimaryEmail         string
+	PhoneNumbers         []string
+	Groups               []UserGroup
+	Approvals            []Approval
+	Active               bool
+	Verified             bool
+	Origin               string
+	ZoneID               string
+	PasswordLastModified time.Time
+	LastLogonTime        time.Time
+	PreviousLogonTime    time.Time
+	Meta                 Meta
+
+	// Only sent when creating users, UAA never returns it
+	Password string
 }
 
 type Name struct {
@@ -24,7 +41,172 @@ type Name struct {
 	GivenName  string
 }
 
-func UserByID(u UAA, id string) (User, error) {
+// Versioning information of SCIM resources. Version is matched against the
+// If-Match header of updates.
+type Meta struct {
+	Version      int       `json:"version"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// Group the user belongs to, Type is DIRECT or INDIRECT for groups the user
+// belongs to through a nested group
+type UserGroup struct {
+	Value   string `json:"value"`
+	Display string `json:"display"`
+	Type    string `json:"type"`
+}
+
+// Decision of the user on a scope requested by a client, Status is APPROVED
+// or DENIED
+type Approval struct {
+	UserID        string    `json:"userId"`
+	ClientID      string    `json:"clientId"`
+	Scope         string    `json:"scope"`
+	Status        string    `json:"status"`
+	LastUpdatedAt time.Time `json:"lastUpdatedAt"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+}
+
+// SCIM resource of a user, the way UAA sends and receives users
+type userJSON struct {
+	Schemas              []string        `json:"schemas,omitempty"`
+	ID                   string          `json:"id,omitempty"`
+	ExternalID           string          `json:"externalId,omitempty"`
+	Meta                 *Meta           `json:"meta,omitempty"`
+	UserName             string          `json:"userName"`
+	Password             string          `json:"password,omitempty"`
+	Name                 nameJSON        `json:"name"`
+	Emails               []userValueJSON `json:"emails"`
+	PhoneNumbers         []userValueJSON `json:"phoneNumbers,omitempty"`
+	Groups               []UserGroup     `json:"groups,omitempty"`
+	Approvals            []Approval      `json:"approvals,omitempty"`
+	Active               bool            `json:"active"`
+	Verified             bool            `json:"verified"`
+	Origin               string          `json:"origin,omitempty"`
+	ZoneID               string          `json:"zoneId,omitempty"`
+	PasswordLastModified *time.Time      `json:"passwordLastModified,omitempty"`
+	LastLogonTime        *int64          `json:"lastLogonTime,omitempty"`
+	PreviousLogonTime    *int64          `json:"previousLogonTime,omitempty"`
+}
+
+type nameJSON struct {
+	FamilyName string `json:"familyName,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+}
+
+type userValueJSON struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+func newUserJSON(user User) userJSON {
+	encoded := userJSON{
+		Schemas:           []string{"urn:scim:schemas:core:1.0"},
+		ID:                user.ID,
+		ExternalID:        user.ExternalID,
+		UserName:          user.Username,
+		Password:          user.Password,
+		Name:              nameJSON(user.Name),
+		Emails:            []userValueJSON{},
+		Groups:            user.Groups,
+		Approvals:         user.Approvals,
+		Active:            user.Active,
+		Verified:          user.Verified,
+		Origin:            user.Origin,
+		ZoneID:            user.ZoneID,
+		LastLogonTime:     millisecondsFromTime(user.LastLogonTime),
+		PreviousLogonTime: millisecondsFromTime(user.PreviousLogonTime),
+	}
+
+	if user.Meta != (Meta{}) {
+		meta := user.Meta
+		encoded.Meta = &meta
+	}
+
+	if !user.PasswordLastModified.IsZero() {
+		passwordLastModified := user.PasswordLastModified
+		encoded.PasswordLastModified = &passwordLastModified
+	}
+
+	for _, email := range user.Emails {
+		encoded.Emails = append(encoded.Emails, userValueJSON{Value: email, Primary: email == user.PrimaryEmail})
+	}
+
+	for _, phoneNumber := range user.PhoneNumbers {
+		encoded.PhoneNumbers = append(encoded.PhoneNumbers, userValueJSON{Value: phoneNumber})
+	}
+
+	return encoded
+}
+
+func (decoded userJSON) user() User {
+	user := User{
+		Username:          decoded.UserName,
+		ID:                decoded.ID,
+		ExternalID:        decoded.ExternalID,
+		Name:              Name(decoded.Name),
+		Groups:            decoded.Groups,
+		Approvals:         decoded.Approvals,
+		Active:            decoded.Active,
+		Verified:          decoded.Verified,
+		Origin:            decoded.Origin,
+		ZoneID:            decoded.ZoneID,
+		LastLogonTime:     timeFromMilliseconds(decoded.LastLogonTime),
+		PreviousLogonTime: timeFromMilliseconds(decoded.PreviousLogonTime),
+		Password:          decoded.Password,
+	}
+
+	if decoded.Meta != nil {
+		user.Meta = *decoded.Meta
+	}
+
+	if decoded.PasswordLastModified != nil {
+		user.PasswordLastModified = *decoded.PasswordLastModified
+	}
+
+	for _, email := range decoded.Emails {
+		user.Emails = append(user.Emails, email.Value)
+		if email.Primary {
+			user.PrimaryEmail = email.Value
+		}
+	}
+
+	for _, phoneNumber := range decoded.PhoneNumbers {
+		user.PhoneNumbers = append(user.PhoneNumbers, phoneNumber.Value)
+	}
+
+	return user
+}
+
+func usersFromJSON(decoded []userJSON) []User {
+	users := make([]User, 0, len(decoded))
+	for _, user := range decoded {
+		users = append(users, user.user())
+	}
+	return users
+}
+
+// UAA reports logon times in milliseconds since the epoch
+func millisecondsFromTime(t time.Time) *int64 {
+	if t.IsZero() {
+		return nil
+	}
+	milliseconds := t.UnixNano() / int64(time.Millisecond)
+	return &milliseconds
+}
+
+func timeFromMilliseconds(milliseconds *int64) time.Time {
+	if milliseconds == nil {
+		return time.Time{}
+	}
+	return time.Unix(0, *milliseconds*int64(time.Millisecond)).UTC()
+}
+
+func UserByID(u UAA, id string) (_ User, err error) {
+	u, span := u.withOperation("UserByID")
+	defer span.end(&err)
+
 	user := User{
 		ID: id,
 	}
@@ -34,8 +216,7 @@ func UserByID(u UAA, id string) (User, error) {
 		return user, err
 	}
 
-	host := uri.Scheme + "://" + uri.Host
-	client := NewClient(host, u.VerifySSL).WithAuthorizationToken(u.AccessToken)
+	client := u.newAdminClient(uri)
 	code, body, err := client.MakeRequest("GET", uri.RequestURI(), nil)
 	if err != nil {
 		return user, err
@@ -53,63 +234,22 @@ func UserByID(u UAA, id string) (User, error) {
 	return user, nil
 }
 
+// Decodes a user from a SCIM resource
 func UserFromJSON(jsonBytes []byte) (User, error) {
-	var parsed map[string]interface{}
-	err := json.Unmarshal(jsonBytes, &parsed)
+	var decoded userJSON
+	err := json.Unmarshal(jsonBytes, &decoded)
 	if err != nil {
 		return User{}, err
 	}
-
-	return UserFromResource(parsed)
+	return decoded.user(), nil
 }
 
+// Decodes a user from a resource that was already decoded into a map
 func UserFromResource(resource map[string]interface{}) (User, error) {
-	user := User{}
-
-	userName, ok := resource["userName"].(string)
-	if ok {
-		user.Username = userName
-	}
-
-	id, ok := resource["id"].(string)
-	if ok {
-		user.ID = id
-	}
-
-	active, ok := resource["active"].(bool)
-	if ok {
-		user.Active = active
-	}
-
-	verified, ok := resource["verified"].(bool)
-	if ok {
-		user.Verified = verified
-	}
-
-	name, ok := resource["name"].(map[string]interface{})
-	if ok {
-		givenName, ok := name["givenName"].(string)
-		if ok {
-			user.Name.GivenName = givenName
-		}
-
-		familyName, ok := name["familyName"].(string)
-		if ok {
-			user.Name.FamilyName = familyName
-		}
+	jsonBytes, err := json.Marshal(resource)
+	if err != nil {
+		return User{}, err
 	}
 
-	emailInterfaces, ok := resource["emails"].([]interface{})
-	if ok {
-		for _, emailInterface := range emailInterfaces {
-			emailMap, ok := emailInterface.(map[string]interface{})
-			if ok {
-				email, ok := emailMap["value"].(string)
-				if ok {
-					user.Emails = append(user.Emails, email)
-				}
-			}
-		}
-	}
-	return user, nil
+	return UserFromJSON(jsonBytes)
 }
@@ -1,9 +1,11 @@
 package uaa_test
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"time"
 
 	"github.com/pivotal-cf/uaa-sso-golang/uaa"
 
@@ -11,6 +13,21 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// Meta and groups shared by the user fixtures
+var fixtureMeta = uaa.Meta{
+	Version:      6,
+	Created:      time.Date(2014, 5, 22, 22, 36, 36, 941000000, time.UTC),
+	LastModified: time.Date(2014, 6, 25, 23, 10, 3, 845000000, time.UTC),
+}
+
+var fixtureGroups = []uaa.UserGroup{
+	{
+		Value:   "e7f74565-4c7e-44ba-b068-b16072cbf08f",
+		Display: "clients.read",
+		Type:    "DIRECT",
+	},
+}
+
 var _ = Describe("UserByID", func() {
 	var fakeUAAServer *httptest.Server
 	var auth uaa.UAA
@@ -76,9 +93,12 @@ var _ = Describe("UserByID", func() {
 					FamilyName: "Admin",
 					GivenName:  "Mister",
 				},
-				Emails:   []string{"fake-user@example.com"},
-				Active:   true,
-				Verified: false,
+				Emails:    []string{"fake-user@example.com"},
+				Active:    true,
+				Verified:  false,
+				Groups:    fixtureGroups,
+				Approvals: []uaa.Approval{},
+				Meta:      fixtureMeta,
 			}))
 		})
 	})
@@ -106,4 +126,108 @@ var _ = Describe("UserByID", func() {
 			Expect(err.Error()).To(Equal(`UAA Failure: 401 {"errors": "Unauthorized"}`))
 		})
 	})
+
+	Describe("UserFromJSON", func() {
+		It("parses every attribute UAA returns", func() {
+			user, err := uaa.UserFromJSON([]byte(`{
+              "id": "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929",
+              "externalId": "cn=jane,dc=example,dc=com",
+              "meta": {
+                "version": 6,
+                "created": "2014-05-22T22:36:36.941Z",
+                "lastModified": "2014-06-25T23:10:03.845Z"
+              },
+              "userName": "jane",
+              "name": {
+                "familyName": "Doe",
+                "givenName": "Jane"
+              },
+              "emails": [
+                {"value": "jane@example.com", "primary": false},
+                {"value": "jane.doe@example.com", "primary": true}
+              ],
+              "phoneNumbers": [
+                {"value": "5555555555"}
+              ],
+              "groups": [
+                {"value": "e7f74565-4c7e-44ba-b068-b16072cbf08f", "display": "clients.read", "type": "DIRECT"},
+                {"value": "4a6a1ab6-4f55-4ac5-a0a1-1ff1e4c3fa8e", "display": "uaa.user", "type": "INDIRECT"}
+              ],
+              "approvals": [
+                {
+                  "userId": "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929",
+                  "clientId": "app",
+                  "scope": "openid",
+                  "status": "APPROVED",
+                  "lastUpdatedAt": "2017-08-07T23:16:18.369Z",
+                  "expiresAt": "2017-08-08T23:16:18.369Z"
+                }
+              ],
+              "active": true,
+              "verified": true,
+              "origin": "ldap",
+              "zoneId": "uaa",
+              "passwordLastModified": "2014-05-22T22:36:36.000Z",
+              "lastLogonTime": 1502148178369,
+              "previousLogonTime": 1502061778369,
+              "schemas": ["urn:scim:schemas:core:1.0"]
+            }`))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(user).To(Equal(uaa.User{
+				Username:     "jane",
+				ID:           "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929",
+				ExternalID:   "cn=jane,dc=example,dc=com",
+				Name:         uaa.Name{FamilyName: "Doe", GivenName: "Jane"},
+				Emails:       []string{"jane@example.com", "jane.doe@example.com"},
+				PrimaryEmail: "jane.doe@example.com",
+				PhoneNumbers: []string{"5555555555"},
+				Groups: []uaa.UserGroup{
+					fixtureGroups[0],
+					{Value: "4a6a1ab6-4f55-4ac5-a0a1-1ff1e4c3fa8e", Display: "uaa.user", Type: "INDIRECT"},
+				},
+				Approvals: []uaa.Approval{
+					{
+						UserID:        "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929",
+						ClientID:      "app",
+						Scope:         "openid",
+						Status:        "APPROVED",
+						LastUpdatedAt: time.Date(2017, 8, 7, 23, 16, 18, 369000000, time.UTC),
+						ExpiresAt:     time.Date(2017, 8, 8, 23, 16, 18, 369000000, time.UTC),
+					},
+				},
+				Active:               true,
+				Verified:             true,
+				Origin:               "ldap",
+				ZoneID:               "uaa",
+				PasswordLastModified: time.Date(2014, 5, 22, 22, 36, 36, 0, time.UTC),
+				LastLogonTime:        time.Date(2017, 8, 7, 23, 22, 58, 369000000, time.UTC),
+				PreviousLogonTime:    time.Date(2017, 8, 6, 23, 22, 58, 369000000, time.UTC),
+				Meta:                 fixtureMeta,
+			}))
+
+			encoded, err := json.Marshal(user)
+			Expect(err).NotTo(HaveOccurred())
+
+			var roundTripped uaa.User
+			Expect(json.Unmarshal(encoded, &roundTripped)).To(Succeed())
+			Expect(roundTripped).To(Equal(user))
+		})
+
+		It("keeps the Go field names in the JSON encoding of User", func() {
+			encoded, err := json.Marshal(uaa.User{Username: "jane", Name: uaa.Name{GivenName: "Jane"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			var fields map[string]interface{}
+			Expect(json.Unmarshal(encoded, &fields)).To(Succeed())
+			Expect(fields["Username"]).To(Equal("jane"))
+			Expect(fields["Name"]).To(Equal(map[string]interface{}{"FamilyName": "", "GivenName": "Jane"}))
+		})
+
+		It("returns an error for attributes of the wrong type", func() {
+			_, err := uaa.UserFromJSON([]byte(`{"userName": 42}`))
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
@@ -0,0 +1,102 @@
+package uaa
+
+// Pages through the users matching a query without holding more than a page
+// of them in memory. Use it like a bufio.Scanner:
+//
+//	users := uaa.NewUserIterator(auth, uaa.UsersQuery{Count: 500})
+//	for users.Next() {
+//		user := users.User()
+//		...
+//	}
+//	if err := users.Err(); err != nil {
+//		...
+//	}
+//
+// Stopping before Next returns false is fine, nothing is left running.
+type UserIterator struct {
+	u     UAA
+	query UsersQuery
+
+	users          []User
+	position       int
+	nextStartIndex int
+	totalResults   int
+	fetched        bool
+
+	user User
+	err  error
+}
+
+// Returns an iterator over the users matching the query, starting at
+// query.StartIndex and requesting query.Count users per page. Requests are
+// made with the context of the UAA.
+func NewUserIterator(u UAA, query UsersQuery) *UserIterator {
+	startIndex := query.StartIndex
+	if startIndex < 1 {
+		startIndex = 1
+	}
+
+	return &UserIterator{
+		u:              u,
+		query:          query,
+		nextStartIndex: startIndex,
+	}
+}
+
+// Advances to the next user, fetching the next page when needed. Returns
+// false when there are no more users or a request failed, see Err.
+func (iterator *UserIterator) Next() bool {
+	if iterator.err != nil {
+		return false
+	}
+
+	if iterator.position >= len(iterator.users) {
+		if iterator.fetched && iterator.nextStartIndex > iterator.totalResults {
+			return false
+		}
+
+		err := iterator.u.Context().Err()
+		if err != nil {
+			iterator.err = err
+			return false
+		}
+
+		query := iterator.query
+		query.StartIndex = iterator.nextStartIndex
+		page, err := SearchUsers(iterator.u, query)
+		if err != nil {
+			iterator.err = err
+			return false
+		}
+
+		iterator.fetched = true
+		iterator.users = page.Users
+		iterator.position = 0
+		iterator.totalResults = page.TotalResults
+		iterator.nextStartIndex += len(page.Users)
+
+		if len(page.Users) == 0 {
+			return false
+		}
+	}
+
+	iterator.user = iterator.users[iterator.position]
+	iterator.position++
+	return true
+}
+
+// Returns the user Next advanced to
+func (iterator *UserIterator) User() User {
+	return iterator.user
+}
+
+// Returns the error that stopped the iteration, if any
+func (iterator *UserIterator) Err() error {
+	return iterator.err
+}
+
+// Returns the start index of the next user Next would advance to. Pass it as
+// UsersQuery.StartIndex to resume the iteration later.
+func (iterator *UserIterator) StartIndex() int {
+	return iterator.nextStartIndex - (len(iterator.users) - iterator.position)
+}
@@ -0,0 +1,121 @@
+package uaa_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UserIterator", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var startIndexes []string
+	var failAtStartIndex string
+
+	BeforeEach(func() {
+		startIndexes = []string{}
+		failAtStartIndex = ""
+		fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			query := req.URL.Query()
+			startIndexes = append(startIndexes, query.Get("startIndex"))
+
+			if query.Get("startIndex") == failAtStartIndex {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"error":"server_error"}`))
+				return
+			}
+
+			startIndex, _ := strconv.Atoi(query.Get("startIndex"))
+			count, _ := strconv.Atoi(query.Get("count"))
+			resources := []map[string]string{}
+			for i := startIndex; i < startIndex+count && i <= 7; i++ {
+				resources = append(resources, map[string]string{"id": fmt.Sprintf("user-%d", i)})
+			}
+
+			response, err := json.Marshal(map[string]interface{}{
+				"resources":    resources,
+				"startIndex":   startIndex,
+				"itemsPerPage": len(resources),
+				"totalResults": 7,
+			})
+			if err != nil {
+				panic(err)
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write(response)
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	ids := func(iterator *uaa.UserIterator, limit int) []string {
+		ids := []string{}
+		for len(ids) < limit && iterator.Next() {
+			ids = append(ids, iterator.User().ID)
+		}
+		return ids
+	}
+
+	It("yields every user, a page at a time", func() {
+		iterator := uaa.NewUserIterator(auth, uaa.UsersQuery{Count: 3})
+
+		Expect(ids(iterator, 100)).To(Equal([]string{"user-1", "user-2", "user-3", "user-4", "user-5", "user-6", "user-7"}))
+		Expect(iterator.Err()).NotTo(HaveOccurred())
+		Expect(startIndexes).To(Equal([]string{"1", "4", "7"}))
+		Expect(iterator.Next()).To(BeFalse())
+		Expect(startIndexes).To(HaveLen(3))
+	})
+
+	It("only fetches the pages that are needed when stopped early", func() {
+		iterator := uaa.NewUserIterator(auth, uaa.UsersQuery{Count: 3})
+
+		Expect(ids(iterator, 4)).To(Equal([]string{"user-1", "user-2", "user-3", "user-4"}))
+		Expect(startIndexes).To(Equal([]string{"1", "4"}))
+		Expect(iterator.StartIndex()).To(Equal(5))
+	})
+
+	It("resumes from a start index", func() {
+		iterator := uaa.NewUserIterator(auth, uaa.UsersQuery{Count: 3, StartIndex: 5})
+
+		Expect(ids(iterator, 100)).To(Equal([]string{"user-5", "user-6", "user-7"}))
+		Expect(startIndexes).To(Equal([]string{"5"}))
+		Expect(iterator.StartIndex()).To(Equal(8))
+	})
+
+	It("stops at the first error and reports it", func() {
+		failAtStartIndex = "4"
+		iterator := uaa.NewUserIterator(auth, uaa.UsersQuery{Count: 3})
+
+		Expect(ids(iterator, 100)).To(Equal([]string{"user-1", "user-2", "user-3"}))
+		Expect(iterator.Err()).To(BeAssignableToTypeOf(uaa.Failure{}))
+		Expect(iterator.Err().(uaa.Failure).Code()).To(Equal(http.StatusInternalServerError))
+		Expect(iterator.StartIndex()).To(Equal(4))
+
+		Expect(iterator.Next()).To(BeFalse())
+		Expect(startIndexes).To(HaveLen(2))
+	})
+
+	It("stops when the context of the UAA is canceled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		iterator := uaa.NewUserIterator(auth.WithContext(ctx), uaa.UsersQuery{Count: 3})
+
+		Expect(ids(iterator, 3)).To(HaveLen(3))
+		cancel()
+
+		Expect(iterator.Next()).To(BeFalse())
+		Expect(errors.Is(iterator.Err(), context.Canceled)).To(BeTrue())
+		Expect(startIndexes).To(HaveLen(1))
+	})
+})
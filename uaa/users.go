@@ -0,0 +1,99 @@
+package uaa
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Version matching any version of a resource, updates made with it always
+// apply
+const AnyVersion = -1
+
+// Returned by updates of resources without Meta, which were not read from
+// UAA. Set Meta.Version to AnyVersion to update them whatever their version.
+var MissingVersionError = errors.New("Missing resource version")
+
+type UserManagementInterface interface {
+	CreateUser(User) (User, error)
+	UpdateUser(User) (User, error)
+	PatchUser(string, int, map[string]interface{}) (User, error)
+	DeleteUser(string) error
+}
+
+// Creates the user, Password is required for users of the uaa origin. A
+// username that is already taken is reported as a ConflictError. Users are
+// always created active, deactivate them with UpdateUser or PatchUser.
+func CreateUser(u UAA, user User) (_ User, err error) {
+	u, span := u.withOperation("CreateUser")
+	defer span.end(&err)
+
+	user.Active = true
+
+	var created userJSON
+	err = u.adminRequest("POST", "/Users", nil, newUserJSON(user), &created)
+	return created.user(), err
+}
+
+// Replaces the user. The update only applies when the user is still at
+// user.Meta.Version, otherwise a ConflictError is returned. Users without
+// Meta, which were not read from UAA, are rejected with MissingVersionError
+// unless Meta.Version is set to AnyVersion.
+func UpdateUser(u UAA, user User) (_ User, err error) {
+	u, span := u.withOperation("UpdateUser")
+	defer span.end(&err)
+
+	version, err := metaVersion(&user.Meta)
+	if err != nil {
+		return User{}, err
+	}
+	user.Password = ""
+
+	var updated userJSON
+	err = u.adminRequest("PUT", "/Users/"+url.PathEscape(user.ID), ifMatch(version), newUserJSON(user), &updated)
+	return updated.user(), err
+}
+
+// Changes only the given SCIM attributes of the user, like
+// {"name": {"givenName": "Jane"}}, when the user is still at the given
+// version. Use AnyVersion to patch the user whatever its version.
+func PatchUser(u UAA, id string, version int, attributes map[string]interface{}) (_ User, err error) {
+	u, span := u.withOperation("PatchUser")
+	defer span.end(&err)
+
+	var patched userJSON
+	err = u.adminRequest("PATCH", "/Users/"+url.PathEscape(id), ifMatch(version), attributes, &patched)
+	return patched.user(), err
+}
+
+func DeleteUser(u UAA, id string) (err error) {
+	u, span := u.withOperation("DeleteUser")
+	defer span.end(&err)
+
+	return u.adminRequest("DELETE", "/Users/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// Returns the version to update a resource at, clearing Meta when any version
+// was asked for so that it is not sent along
+func metaVersion(meta *Meta) (int, error) {
+	if *meta == (Meta{}) {
+		return 0, MissingVersionError
+	}
+
+	version := meta.Version
+	if version == AnyVersion {
+		*meta = Meta{}
+	}
+	return version, nil
+}
+
+func ifMatch(version int) http.Header {
+	header := http.Header{}
+	if version == AnyVersion {
+		header.Set("If-Match", "*")
+	} else {
+		header.Set("If-Match", strconv.Itoa(version))
+	}
+	return header
+}
@@ -0,0 +1,33 @@
+package uaa
+
+type UsersByEmailsInterface interface {
+	UsersByEmails(...string) ([]User, error)
+	LookupUsersByEmails(...string) (UsersLookup, error)
+}
+
+// Returns the users with any of the given email addresses. An address may
+// belong to several users, in one identity origin or across several.
+func UsersByEmails(u UAA, emails ...string) ([]User, error) {
+	return UsersByEmailsWithMaxLength(u, MaxQueryLength, emails...)
+}
+
+// Splits the addresses into queries that fit in URLs of the given length
+// and sends them concurrently, like UsersByIDsWithMaxLength
+func UsersByEmailsWithMaxLength(u UAA, length int, emails ...string) (_ []User, err error) {
+	u, span := u.withOperation("UsersByEmails")
+	defer span.end(&err)
+	return usersByAttribute(u, length, "emails.value", Filter{}, emails)
+}
+
+// Returns the users keyed by email address. Addresses shared by several
+// users are reported in Duplicates.
+func LookupUsersByEmails(u UAA, emails ...string) (UsersLookup, error) {
+	users, err := UsersByEmails(u, emails...)
+	if err != nil {
+		return newEmptyUsersLookup(), err
+	}
+
+	return newUsersLookup(uniqueStrings(emails), users, func(user User) []string {
+		return user.Emails
+	}), nil
+}
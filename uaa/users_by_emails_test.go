@@ -0,0 +1,142 @@
+package uaa_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UsersByEmails", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var mutex sync.Mutex
+	var filters []string
+
+	BeforeEach(func() {
+		filters = []string{}
+		fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mutex.Lock()
+			filters = append(filters, req.URL.Query().Get("filter"))
+			mutex.Unlock()
+
+			if req.URL.Path != "/Users" || req.Header.Get("Authorization") != "Bearer my-special-token" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			if req.URL.Query().Get("filter") == `emails.value eq "broken"` {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"scim_exception","error_description":"Invalid filter"}`))
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+                "resources": [
+                    {"id": "user-1", "userName": "first", "emails": [{"value": "First@corp.com"}, {"value": "shared@corp.com"}]},
+                    {"id": "user-2", "userName": "second", "emails": [{"value": "shared@corp.com"}]}
+                ],
+                "startIndex": 1,
+                "itemsPerPage": 2,
+                "totalResults": 2
+            }`))
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	It("looks the users up by email address", func() {
+		users, err := uaa.UsersByEmails(auth, "first@corp.com", "shared@corp.com", "first@corp.com")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(users).To(HaveLen(2))
+		Expect(filters).To(Equal([]string{`emails.value eq "first@corp.com" or emails.value eq "shared@corp.com"`}))
+	})
+
+	It("respects the maximum length of a URL", func() {
+		_, err := uaa.UsersByEmailsWithMaxLength(auth, 90, "first@corp.com", "shared@corp.com")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(filters).To(ConsistOf(`emails.value eq "first@corp.com"`, `emails.value eq "shared@corp.com"`))
+	})
+
+	It("finds every user sharing an address, past UAA's page size", func() {
+		handler := fakeUAAServer.Config.Handler
+		fakeUAAServer.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Query().Get("filter") != `emails.value eq "team@corp.com"` {
+				handler.ServeHTTP(w, req)
+				return
+			}
+
+			startIndex := 1
+			if req.URL.Query().Get("startIndex") != "" {
+				startIndex, _ = strconv.Atoi(req.URL.Query().Get("startIndex"))
+			}
+
+			resources := []map[string]interface{}{}
+			for i := startIndex; i < startIndex+100 && i <= 150; i++ {
+				resources = append(resources, map[string]interface{}{
+					"id":     fmt.Sprintf("member-%d", i),
+					"emails": []map[string]string{{"value": "team@corp.com"}},
+				})
+			}
+
+			response, err := json.Marshal(map[string]interface{}{
+				"resources":    resources,
+				"startIndex":   startIndex,
+				"itemsPerPage": len(resources),
+				"totalResults": 150,
+			})
+			if err != nil {
+				panic(err)
+			}
+			w.Write(response)
+		})
+
+		lookup, err := uaa.LookupUsersByEmails(auth, "team@corp.com")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(lookup.Duplicates["team@corp.com"]).To(HaveLen(150))
+	})
+
+	Describe("LookupUsersByEmails", func() {
+		It("keys the users by address and reports addresses shared by several users", func() {
+			lookup, err := uaa.LookupUsersByEmails(auth, "first@corp.com", "shared@corp.com", "nobody@corp.com")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(lookup.Users).To(HaveLen(1))
+			Expect(lookup.Users["first@corp.com"].ID).To(Equal("user-1"))
+			Expect(lookup.Duplicates).To(HaveLen(1))
+			Expect(lookup.Duplicates["shared@corp.com"]).To(HaveLen(2))
+			Expect(lookup.NotFound).To(Equal([]string{"nobody@corp.com"}))
+		})
+
+		It("does not count a user found by several chunks twice", func() {
+			lookup, err := uaa.LookupUsersByEmails(auth, "First@corp.com")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lookup.Users["First@corp.com"].ID).To(Equal("user-1"))
+
+			lookup, err = uaa.LookupUsersByEmails(auth, "first@corp.com", "nobody@corp.com")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lookup.Users).To(HaveKey("first@corp.com"))
+		})
+
+		It("returns failures", func() {
+			lookup, err := uaa.LookupUsersByEmails(auth, "broken")
+
+			Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+			Expect(lookup.Users).To(BeEmpty())
+		})
+	})
+})
@@ -1,55 +1,192 @@
 package uaa
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
 const MaxQueryLength = 8000
 
+// Number of chunks UsersByIDs and the other lookups by attribute fetch at
+// once
+const DefaultUsersByIDsConcurrency = 4
+
 type UsersByIDsInterface interface {
 	UsersByIDs(...string) ([]User, error)
 }
 
+type LookupUsersByIDsInterface interface {
+	LookupUsersByIDs(...string) (UsersLookup, error)
+}
+
+// Users found by a lookup, keyed by the value they were looked up by, and
+// the values no user was found for, in the order they were asked for.
+// Values are matched without regard to case, as UAA does. Values matching
+// more than one user, like a username taken in several identity origins, are
+// left out of Users and reported in Duplicates instead.
+type UsersLookup struct {
+	Users      map[string]User
+	NotFound   []string
+	Duplicates map[string][]User
+}
+
 func UsersByIDs(u UAA, ids ...string) ([]User, error) {
 	return UsersByIDsWithMaxLength(u, MaxQueryLength, ids...)
 }
 
-func UsersByIDsWithMaxLength(u UAA, length int, ids ...string) ([]User, error) {
-	var filters []string
-	var uris []string
-	users := []User{}
+// Splits the IDs into queries that fit in URLs of the given length and sends
+// them concurrently. IDs asked for twice are looked up once. Users are
+// returned in the order of the chunks, each in UAA's order.
+func UsersByIDsWithMaxLength(u UAA, length int, ids ...string) (_ []User, err error) {
+	u, span := u.withOperation("UsersByIDs")
+	defer span.end(&err)
+
+	var filters []Filter
+	for _, id := range uniqueStrings(ids) {
+		filters = append(filters, Eq("Id", id))
+	}
+
+	uris := chunkQueryURIs(filters, length, func(filters []Filter) string {
+		return usersFilterURI(u.uaaURL, Or(filters...))
+	})
+
+	return usersFromQueries(u, uris, DefaultUsersByIDsConcurrency)
+}
+
+func LookupUsersByIDs(u UAA, ids ...string) (UsersLookup, error) {
+	return LookupUsersByIDsWithMaxLength(u, MaxQueryLength, ids...)
+}
+
+// Looks the IDs up like UsersByIDsWithMaxLength, and returns the users keyed
+// by ID along with the IDs UAA does not know
+func LookupUsersByIDsWithMaxLength(u UAA, length int, ids ...string) (UsersLookup, error) {
+	users, err := UsersByIDsWithMaxLength(u, length, ids...)
+	if err != nil {
+		return newEmptyUsersLookup(), err
+	}
+
+	return newUsersLookup(uniqueStrings(ids), users, func(user User) []string {
+		return []string{user.ID}
+	}), nil
+}
+
+func newEmptyUsersLookup() UsersLookup {
+	return UsersLookup{
+		Users:      map[string]User{},
+		NotFound:   []string{},
+		Duplicates: map[string][]User{},
+	}
+}
+
+// Matches the wanted values with the values keysOf returns for the users.
+// Users returned by several chunks are only matched once.
+func newUsersLookup(wanted []string, users []User, keysOf func(User) []string) UsersLookup {
+	lookup := newEmptyUsersLookup()
+
+	seen := map[string]bool{}
+	matches := map[string][]User{}
+	for _, user := range users {
+		if seen[user.ID] {
+			continue
+		}
+		seen[user.ID] = true
+
+		for _, key := range uniqueStrings(lowerStrings(keysOf(user))) {
+			matches[key] = append(matches[key], user)
+		}
+	}
+
+	for _, value := range wanted {
+		switch found := matches[strings.ToLower(value)]; len(found) {
+		case 0:
+			lookup.NotFound = append(lookup.NotFound, value)
+		case 1:
+			lookup.Users[value] = found[0]
+		default:
+			lookup.Duplicates[value] = found
+		}
+	}
+
+	return lookup
+}
 
-	for _, id := range ids {
-		filters = append(filters, fmt.Sprintf(`Id eq "%s"`, id))
+// Builds the fewest URIs from uriFromFilters whose filters keep them within
+// length. A filter too long on its own gets a URI of its own.
+func chunkQueryURIs(filters []Filter, length int, uriFromFilters func([]Filter) string) []string {
+	var uris []string
+	if len(filters) == 0 {
+		return uris
 	}
 
 	var start = 0
-	for i, _ := range filters {
-		if len(UsersQueryURIFromParts(u.uaaURL, filters[start:i+1])) > length {
-			uris = append(uris, UsersQueryURIFromParts(u.uaaURL, filters[start:i]))
+	for i := range filters {
+		if i > start && len(uriFromFilters(filters[start:i+1])) > length {
+			uris = append(uris, uriFromFilters(filters[start:i]))
 			start = i
 		}
 	}
 
-	uris = append(uris, UsersQueryURIFromParts(u.uaaURL, filters[start:]))
+	return append(uris, uriFromFilters(filters[start:]))
+}
+
+// Fetches the users matching each URI with at most concurrency requests in
+// flight, and returns them in the order of the URIs
+func usersFromQueries(u UAA, uris []string, concurrency int) ([]User, error) {
+	results := make([][]User, len(uris))
 
-	for _, uri := range uris {
-		usersToAdd, err := UsersFromQuery(u, uri)
+	err := runConcurrently(u, len(uris), concurrency, func(u UAA, i int) error {
+		users, err := UsersFromQuery(u, uris[i])
 		if err != nil {
-			return users, err
+			return err
 		}
-		users = append(users, usersToAdd...)
+
+		results[i] = users
+		return nil
+	})
+
+	users := []User{}
+	if err != nil {
+		return users, err
+	}
+
+	for _, result := range results {
+		users = append(users, result...)
 	}
 	return users, nil
 }
 
+func lowerStrings(values []string) []string {
+	lower := []string{}
+	for _, value := range values {
+		lower = append(lower, strings.ToLower(value))
+	}
+	return lower
+}
+
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+func usersFilterURI(host string, filter Filter) string {
+	return fmt.Sprintf("%s/Users?filter=%s", host, url.QueryEscape(filter.String()))
+}
+
 func UsersQueryURIFromParts(host string, filters []string) string {
-	return fmt.Sprintf("%s/Users?filter=%s", host, url.QueryEscape(strings.Join(filters, " or ")))
+	return usersFilterURI(host, Or(filtersFromExpressions(filters)...))
 }
 
+// Fetches every user matching the query. UAA returns at most 100 users per
+// page by default, so the pages are followed until totalResults is reached.
 func UsersFromQuery(u UAA, uriString string) ([]User, error) {
 	users := []User{}
 	uri, err := url.Parse(uriString)
@@ -57,30 +194,19 @@ func UsersFromQuery(u UAA, uriString string) ([]User, error) {
 		return []User{}, err
 	}
 
-	host := uri.Scheme + "://" + uri.Host
-	client := NewClient(host, u.VerifySSL).WithAuthorizationToken(u.AccessToken)
-	code, body, err := client.MakeRequest("GET", uri.RequestURI(), nil)
-	if err != nil {
-		return users, err
-	}
-
-	if code > 399 {
-		return users, NewFailure(code, body)
-	}
+	err = pageThrough(u, 1, func(startIndex int) (int, int, error) {
+		if startIndex > 1 {
+			query := uri.Query()
+			query.Set("startIndex", strconv.Itoa(startIndex))
+			uri.RawQuery = query.Encode()
+		}
 
-	var response map[string]interface{}
-	err = json.Unmarshal(body, &response)
+		page, totalResults, err := PaginatedUsersFromQuery(u, uri.String())
+		users = append(users, page...)
+		return len(page), totalResults, err
+	})
 	if err != nil {
-		return users, err
-	}
-
-	resources := response["resources"].([]interface{})
-	for _, resource := range resources {
-		user, err := UserFromResource(resource.(map[string]interface{}))
-		if err != nil {
-			return users, err
-		}
-		users = append(users, user)
+		return []User{}, err
 	}
 
 	return users, nil
@@ -2,10 +2,13 @@ package uaa_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/pivotal-cf/uaa-sso-golang/uaa"
 
@@ -100,13 +103,19 @@ var users = map[string]map[string]interface{}{
 var _ = Describe("UsersByIds", func() {
 	var fakeUAAServer *httptest.Server
 	var auth uaa.UAA
+	var mutex sync.Mutex
 	var requestCount int
+	var filters []string
 
 	Context("when UAA is responding normally", func() {
 		BeforeEach(func() {
 			requestCount = 0
+			filters = []string{}
 			fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mutex.Lock()
 				requestCount += 1
+				filters = append(filters, req.URL.Query().Get("filter"))
+				mutex.Unlock()
 				if req.URL.Path == "/Users" && req.Method == "GET" && strings.Contains(req.Header.Get("Authorization"), "Bearer my-special-token") {
 					responseObj := map[string]interface{}{
 						"resources":    []interface{}{},
@@ -133,6 +142,7 @@ var _ = Describe("UsersByIds", func() {
 					}
 
 					responseObj["resources"] = usersList
+					responseObj["totalResults"] = len(usersList)
 
 					response, err := json.Marshal(responseObj)
 					if err != nil {
@@ -165,9 +175,12 @@ var _ = Describe("UsersByIds", func() {
 					FamilyName: "Admin",
 					GivenName:  "Mister",
 				},
-				Emails:   []string{"fake-user@example.com"},
-				Active:   true,
-				Verified: false,
+				Emails:    []string{"fake-user@example.com"},
+				Active:    true,
+				Verified:  false,
+				Groups:    fixtureGroups,
+				Approvals: []uaa.Approval{},
+				Meta:      fixtureMeta,
 			}
 
 			user2 := uaa.User{
@@ -177,9 +190,12 @@ var _ = Describe("UsersByIds", func() {
 					FamilyName: "Other",
 					GivenName:  "User",
 				},
-				Emails:   []string{"other-user@example.com"},
-				Active:   true,
-				Verified: false,
+				Emails:    []string{"other-user@example.com"},
+				Active:    true,
+				Verified:  false,
+				Groups:    fixtureGroups,
+				Approvals: []uaa.Approval{},
+				Meta:      fixtureMeta,
 			}
 
 			Expect(users).To(Equal([]uaa.User{user1, user2}))
@@ -197,6 +213,123 @@ var _ = Describe("UsersByIds", func() {
 
 			Expect(requestCount).To(Equal(2))
 		})
+
+		It("fetches the chunks concurrently and returns them in order", func() {
+			ids := []string{
+				"87dfc5b4-daf9-49fd-9aa8-bb1e21d28929",
+				"unknown-1",
+				"baf908c9-3248-451f-ab3c-103d921cd61e",
+				"unknown-2",
+			}
+			users, err := uaa.UsersByIDsWithMaxLength(auth, 100, ids...)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(users).To(HaveLen(2))
+			Expect(users[0].ID).To(Equal("87dfc5b4-daf9-49fd-9aa8-bb1e21d28929"))
+			Expect(users[1].ID).To(Equal("baf908c9-3248-451f-ab3c-103d921cd61e"))
+
+			Expect(filters).To(ConsistOf(
+				`Id eq "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929"`,
+				`Id eq "unknown-1"`,
+				`Id eq "baf908c9-3248-451f-ab3c-103d921cd61e"`,
+				`Id eq "unknown-2"`,
+			))
+		})
+
+		It("looks IDs asked for twice up once", func() {
+			users, err := uaa.UsersByIDs(auth, "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", "baf908c9-3248-451f-ab3c-103d921cd61e", "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(users).To(HaveLen(2))
+			Expect(filters).To(Equal([]string{`Id eq "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929" or Id eq "baf908c9-3248-451f-ab3c-103d921cd61e"`}))
+		})
+
+		It("does not ask UAA for every user when no IDs are given", func() {
+			users, err := uaa.UsersByIDs(auth)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(users).To(BeEmpty())
+			Expect(requestCount).To(Equal(0))
+		})
+
+		Describe("LookupUsersByIDs", func() {
+			It("returns the users keyed by ID and the IDs that were not found", func() {
+				lookup, err := uaa.LookupUsersByIDs(auth, "unknown-1", "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", "unknown-1", "baf908c9-3248-451f-ab3c-103d921cd61e", "unknown-2")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(lookup.Users).To(HaveLen(2))
+				Expect(lookup.Users["87dfc5b4-daf9-49fd-9aa8-bb1e21d28929"].Username).To(Equal("admin"))
+				Expect(lookup.Users["baf908c9-3248-451f-ab3c-103d921cd61e"].Username).To(Equal("other"))
+				Expect(lookup.NotFound).To(Equal([]string{"unknown-1", "unknown-2"}))
+			})
+
+			It("splits the lookup like UsersByIDsWithMaxLength", func() {
+				lookup, err := uaa.LookupUsersByIDsWithMaxLength(auth, 100, "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", "unknown-1")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(lookup.Users).To(HaveKey("87dfc5b4-daf9-49fd-9aa8-bb1e21d28929"))
+				Expect(lookup.NotFound).To(Equal([]string{"unknown-1"}))
+				Expect(requestCount).To(Equal(2))
+			})
+		})
+	})
+
+	Context("when a chunk matches more users than UAA returns in a page", func() {
+		var ids []string
+		var startIndexes []string
+
+		BeforeEach(func() {
+			ids = []string{}
+			for i := 1; i <= 150; i++ {
+				ids = append(ids, fmt.Sprintf("user-%03d", i))
+			}
+			startIndexes = []string{}
+
+			fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				startIndexes = append(startIndexes, req.URL.Query().Get("startIndex"))
+
+				matcher := regexp.MustCompile(`Id eq "([a-zA-Z0-9\-]*)"`)
+				matches := matcher.FindAllStringSubmatch(req.URL.Query().Get("filter"), -1)
+
+				startIndex := 1
+				if req.URL.Query().Get("startIndex") != "" {
+					startIndex, _ = strconv.Atoi(req.URL.Query().Get("startIndex"))
+				}
+
+				// UAA's default page size
+				resources := []map[string]string{}
+				for i := startIndex; i < startIndex+100 && i <= len(matches); i++ {
+					resources = append(resources, map[string]string{"id": matches[i-1][1]})
+				}
+
+				response, err := json.Marshal(map[string]interface{}{
+					"resources":    resources,
+					"startIndex":   startIndex,
+					"itemsPerPage": len(resources),
+					"totalResults": len(matches),
+				})
+				if err != nil {
+					panic(err)
+				}
+				w.Write(response)
+			}))
+			auth = uaa.NewUAA("http://uaa.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+		})
+
+		AfterEach(func() {
+			fakeUAAServer.Close()
+		})
+
+		It("follows the pages of the chunk", func() {
+			users, err := uaa.UsersByIDs(auth, ids...)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(users).To(HaveLen(150))
+			for i, user := range users {
+				Expect(user.ID).To(Equal(ids[i]))
+			}
+			Expect(startIndexes).To(Equal([]string{"", "101"}))
+		})
 	})
 
 	Context("when UAA is not responding normally", func() {
@@ -221,5 +354,18 @@ var _ = Describe("UsersByIds", func() {
 			Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
 			Expect(err.Error()).To(Equal(`UAA Failure: 401 {"errors": "Unauthorized"}`))
 		})
+
+		It("returns the failure of the first chunk that failed", func() {
+			users, err := uaa.UsersByIDsWithMaxLength(auth, 100, "1234", "5678", "9012")
+			Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+			Expect(users).To(BeEmpty())
+		})
+
+		It("returns an empty lookup along with the failure", func() {
+			lookup, err := uaa.LookupUsersByIDs(auth, "1234")
+			Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+			Expect(lookup.Users).To(BeEmpty())
+			Expect(lookup.NotFound).To(BeEmpty())
+		})
 	})
 })
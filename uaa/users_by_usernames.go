@@ -0,0 +1,88 @@
+package uaa
+
+import "fmt"
+
+type UsersByUsernamesInterface interface {
+	UsersByUsernames(...string) ([]User, error)
+	UsersByUsernamesInOrigin(string, ...string) ([]User, error)
+	LookupUsersByUsernames(...string) (UsersLookup, error)
+	UserByUsernameAndOrigin(string, string) (User, error)
+}
+
+// Returns the users with the given usernames in every identity origin, so a
+// username taken in several origins yields several users. Use
+// UsersByUsernamesInOrigin or LookupUsersByUsernames to tell them apart.
+func UsersByUsernames(u UAA, usernames ...string) ([]User, error) {
+	return UsersByUsernamesWithMaxLength(u, MaxQueryLength, usernames...)
+}
+
+// Splits the usernames into queries that fit in URLs of the given length
+// and sends them concurrently, like UsersByIDsWithMaxLength
+func UsersByUsernamesWithMaxLength(u UAA, length int, usernames ...string) (_ []User, err error) {
+	u, span := u.withOperation("UsersByUsernames")
+	defer span.end(&err)
+	return usersByAttribute(u, length, "userName", Filter{}, usernames)
+}
+
+// Returns the users with the given usernames in one identity origin, like
+// "uaa" or "ldap"
+func UsersByUsernamesInOrigin(u UAA, origin string, usernames ...string) ([]User, error) {
+	return UsersByUsernamesInOriginWithMaxLength(u, MaxQueryLength, origin, usernames...)
+}
+
+func UsersByUsernamesInOriginWithMaxLength(u UAA, length int, origin string, usernames ...string) (_ []User, err error) {
+	u, span := u.withOperation("UsersByUsernamesInOrigin")
+	defer span.end(&err)
+	return usersByAttribute(u, length, "userName", Eq("origin", origin), usernames)
+}
+
+// Returns the users keyed by username. Usernames taken in several identity
+// origins are reported in Duplicates.
+func LookupUsersByUsernames(u UAA, usernames ...string) (UsersLookup, error) {
+	users, err := UsersByUsernames(u, usernames...)
+	if err != nil {
+		return newEmptyUsersLookup(), err
+	}
+
+	return newUsersLookup(uniqueStrings(usernames), users, func(user User) []string {
+		return []string{user.Username}
+	}), nil
+}
+
+// Returns the user with the username in the identity origin. The error
+// matches NotFoundError when there is none.
+func UserByUsernameAndOrigin(u UAA, username, origin string) (_ User, err error) {
+	u, span := u.withOperation("UserByUsernameAndOrigin")
+	defer span.end(&err)
+
+	users, err := UsersFromQuery(u, usersFilterURI(u.uaaURL, And(Eq("userName", username), Eq("origin", origin))))
+	if err != nil {
+		return User{}, err
+	}
+
+	if len(users) == 0 {
+		return User{}, fmt.Errorf("%w: no user %q in origin %q", NotFoundError, username, origin)
+	}
+
+	return users[0], nil
+}
+
+// Looks up the users whose attribute equals one of the values, within scope
+// when it is not zero. Values like email addresses may match more users than
+// UAA returns in a page, UsersFromQuery follows the pages of every chunk.
+func usersByAttribute(u UAA, length int, attribute string, scope Filter, values []string) ([]User, error) {
+	var filters []Filter
+	for _, value := range uniqueStrings(values) {
+		filters = append(filters, Eq(attribute, value))
+	}
+
+	uris := chunkQueryURIs(filters, length, func(filters []Filter) string {
+		filter := Or(filters...)
+		if !scope.IsZero() {
+			filter = And(filter, scope)
+		}
+		return usersFilterURI(u.uaaURL, filter)
+	})
+
+	return usersFromQueries(u, uris, DefaultUsersByIDsConcurrency)
+}
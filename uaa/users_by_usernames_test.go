@@ -0,0 +1,170 @@
+package uaa_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UsersByUsernames", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var mutex sync.Mutex
+	var filters []string
+
+	usersByOrigin := []map[string]interface{}{
+		{"id": "uaa-admin", "userName": "admin", "origin": "uaa"},
+		{"id": "ldap-admin", "userName": "Admin", "origin": "ldap"},
+		{"id": "uaa-other", "userName": "other", "origin": "uaa"},
+	}
+
+	BeforeEach(func() {
+		filters = []string{}
+		fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			filter := req.URL.Query().Get("filter")
+
+			mutex.Lock()
+			filters = append(filters, filter)
+			mutex.Unlock()
+
+			if req.URL.Path != "/Users" || req.Header.Get("Authorization") != "Bearer my-special-token" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			if strings.Contains(filter, "broken") {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"scim_exception","error_description":"Invalid filter"}`))
+				return
+			}
+
+			usernames := map[string]bool{}
+			for _, match := range regexp.MustCompile(`userName eq "([^"]*)"`).FindAllStringSubmatch(filter, -1) {
+				usernames[strings.ToLower(match[1])] = true
+			}
+			origin := ""
+			if match := regexp.MustCompile(`origin eq "([^"]*)"`).FindStringSubmatch(filter); match != nil {
+				origin = match[1]
+			}
+
+			resources := []map[string]interface{}{}
+			for _, user := range usersByOrigin {
+				if usernames[strings.ToLower(user["userName"].(string))] && (origin == "" || user["origin"] == origin) {
+					resources = append(resources, user)
+				}
+			}
+
+			response, err := json.Marshal(map[string]interface{}{
+				"resources":    resources,
+				"startIndex":   1,
+				"itemsPerPage": len(resources),
+				"totalResults": len(resources),
+			})
+			if err != nil {
+				panic(err)
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write(response)
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	It("returns the users with the usernames in every origin", func() {
+		users, err := uaa.UsersByUsernames(auth, "admin", "other", "admin")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(users).To(Equal([]uaa.User{
+			{ID: "uaa-admin", Username: "admin", Origin: "uaa"},
+			{ID: "ldap-admin", Username: "Admin", Origin: "ldap"},
+			{ID: "uaa-other", Username: "other", Origin: "uaa"},
+		}))
+		Expect(filters).To(Equal([]string{`userName eq "admin" or userName eq "other"`}))
+	})
+
+	It("respects the maximum length of a URL", func() {
+		users, err := uaa.UsersByUsernamesWithMaxLength(auth, 70, "admin", "other")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(users).To(HaveLen(3))
+		Expect(filters).To(ConsistOf(`userName eq "admin"`, `userName eq "other"`))
+	})
+
+	It("returns the failure of a chunk", func() {
+		_, err := uaa.UsersByUsernames(auth, "broken")
+		Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+	})
+
+	Describe("UsersByUsernamesInOrigin", func() {
+		It("only returns the users in the origin", func() {
+			users, err := uaa.UsersByUsernamesInOrigin(auth, "ldap", "admin", "other")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(users).To(Equal([]uaa.User{{ID: "ldap-admin", Username: "Admin", Origin: "ldap"}}))
+			Expect(filters).To(Equal([]string{`(userName eq "admin" or userName eq "other") and origin eq "ldap"`}))
+		})
+
+		It("keeps the origin in every chunk", func() {
+			_, err := uaa.UsersByUsernamesInOriginWithMaxLength(auth, 110, "uaa", "admin", "other")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filters).To(ConsistOf(
+				`userName eq "admin" and origin eq "uaa"`,
+				`userName eq "other" and origin eq "uaa"`,
+			))
+		})
+	})
+
+	Describe("LookupUsersByUsernames", func() {
+		It("keys the users by username and reports usernames taken in several origins", func() {
+			lookup, err := uaa.LookupUsersByUsernames(auth, "ADMIN", "other", "nobody")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(lookup.Users).To(Equal(map[string]uaa.User{
+				"other": {ID: "uaa-other", Username: "other", Origin: "uaa"},
+			}))
+			Expect(lookup.Duplicates).To(Equal(map[string][]uaa.User{
+				"ADMIN": {
+					{ID: "uaa-admin", Username: "admin", Origin: "uaa"},
+					{ID: "ldap-admin", Username: "Admin", Origin: "ldap"},
+				},
+			}))
+			Expect(lookup.NotFound).To(Equal([]string{"nobody"}))
+		})
+	})
+
+	Describe("UserByUsernameAndOrigin", func() {
+		It("returns the user with the username in the origin", func() {
+			user, err := uaa.UserByUsernameAndOrigin(auth, "admin", "ldap")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(user).To(Equal(uaa.User{ID: "ldap-admin", Username: "Admin", Origin: "ldap"}))
+			Expect(filters).To(Equal([]string{`userName eq "admin" and origin eq "ldap"`}))
+		})
+
+		It("returns a not found error when the origin has no such user", func() {
+			_, err := uaa.UserByUsernameAndOrigin(auth, "other", "ldap")
+
+			Expect(uaa.IsNotFound(err)).To(BeTrue())
+			Expect(err.Error()).To(Equal(`UAA Not Found: no user "other" in origin "ldap"`))
+		})
+
+		It("returns failures", func() {
+			_, err := uaa.UserByUsernameAndOrigin(auth, "broken", "uaa")
+
+			Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+			Expect(uaa.IsNotFound(err)).To(BeFalse())
+		})
+	})
+})
@@ -3,7 +3,6 @@ package uaa
 import (
 	"fmt"
 	"net/url"
-	"strings"
 )
 
 type UsersEmailsByIDsInterface interface {
@@ -14,35 +13,28 @@ func UsersEmailsByIDs(uaa UAA, ids ...string) ([]User, error) {
 	return UsersEmailsByIDsWithMaxLength(uaa, MaxQueryLength, ids...)
 }
 
-func UsersEmailsByIDsWithMaxLength(u UAA, length int, ids ...string) ([]User, error) {
-	var filters []string
-	var uris []string
-	users := []User{}
+// Like UsersByIDsWithMaxLength, but only asks UAA for the users' IDs and
+// emails. IDs asked for twice are looked up once.
+func UsersEmailsByIDsWithMaxLength(u UAA, length int, ids ...string) (_ []User, err error) {
+	u, span := u.withOperation("UsersEmailsByIDs")
+	defer span.end(&err)
 
-	for _, id := range ids {
-		filters = append(filters, fmt.Sprintf(`Id eq "%s"`, id))
+	var filters []Filter
+	for _, id := range uniqueStrings(ids) {
+		filters = append(filters, Eq("Id", id))
 	}
 
-	var start = 0
-	for i, _ := range filters {
-		if len(UsersEmailsQueryURIFromParts(u.uaaURL, filters[start:i+1])) > length {
-			uris = append(uris, UsersEmailsQueryURIFromParts(u.uaaURL, filters[start:i]))
-			start = i
-		}
-	}
+	uris := chunkQueryURIs(filters, length, func(filters []Filter) string {
+		return usersEmailsFilterURI(u.uaaURL, Or(filters...))
+	})
 
-	uris = append(uris, UsersEmailsQueryURIFromParts(u.uaaURL, filters[start:]))
+	return usersFromQueries(u, uris, DefaultUsersByIDsConcurrency)
+}
 
-	for _, uri := range uris {
-		usersToAdd, err := UsersFromQuery(u, uri)
-		if err != nil {
-			return users, err
-		}
-		users = append(users, usersToAdd...)
-	}
-	return users, nil
+func usersEmailsFilterURI(host string, filter Filter) string {
+	return fmt.Sprintf("%s/Users?attributes=emails,id&filter=%s", host, url.QueryEscape(filter.String()))
 }
 
 func UsersEmailsQueryURIFromParts(host string, filters []string) string {
-	return fmt.Sprintf("%s/Users?attributes=emails,id&filter=%s", host, url.QueryEscape(strings.Join(filters, " or ")))
+	return usersEmailsFilterURI(host, Or(filtersFromExpressions(filters)...))
 }
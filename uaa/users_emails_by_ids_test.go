@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/pivotal-cf/uaa-sso-golang/uaa"
 
@@ -18,10 +20,13 @@ var _ = Describe("UsersEmailsByIds", func() {
 	var fakeUAAServer *httptest.Server
 	var auth uaa.UAA
 	var users map[string]map[string]interface{}
+	var mutex sync.Mutex
 	var requestCount int
+	var filters []string
 
 	BeforeEach(func() {
 		requestCount = 0
+		filters = []string{}
 		users = map[string]map[string]interface{}{
 			"87dfc5b4-daf9-49fd-9aa8-bb1e21d28929": map[string]interface{}{
 				"emails": []map[string]string{
@@ -43,7 +48,10 @@ var _ = Describe("UsersEmailsByIds", func() {
 			},
 		}
 		fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mutex.Lock()
 			requestCount += 1
+			filters = append(filters, req.URL.Query().Get("filter"))
+			mutex.Unlock()
 
 			if req.URL.Path == "/Users" && req.Method == "GET" && strings.Contains(req.Header.Get("Authorization"), "Bearer my-special-token") {
 				responseObj := map[string]interface{}{
@@ -76,6 +84,7 @@ var _ = Describe("UsersEmailsByIds", func() {
 				}
 
 				responseObj["resources"] = usersList
+				responseObj["totalResults"] = len(usersList)
 
 				response, err := json.Marshal(responseObj)
 				if err != nil {
@@ -129,6 +138,36 @@ var _ = Describe("UsersEmailsByIds", func() {
 		Expect(requestCount).To(Equal(2))
 	})
 
+	It("fetches the chunks concurrently and returns them in order", func() {
+		ids := []string{
+			"87dfc5b4-daf9-49fd-9aa8-bb1e21d28929",
+			"unknown-1",
+			"baf908c9-3248-451f-ab3c-103d921cd61e",
+			"unknown-2",
+		}
+		users, err := uaa.UsersEmailsByIDsWithMaxLength(auth, 110, ids...)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(users).To(HaveLen(2))
+		Expect(users[0].ID).To(Equal("87dfc5b4-daf9-49fd-9aa8-bb1e21d28929"))
+		Expect(users[1].ID).To(Equal("baf908c9-3248-451f-ab3c-103d921cd61e"))
+
+		Expect(filters).To(ConsistOf(
+			`Id eq "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929"`,
+			`Id eq "unknown-1"`,
+			`Id eq "baf908c9-3248-451f-ab3c-103d921cd61e"`,
+			`Id eq "unknown-2"`,
+		))
+	})
+
+	It("looks IDs asked for twice up once", func() {
+		users, err := uaa.UsersEmailsByIDs(auth, "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", "baf908c9-3248-451f-ab3c-103d921cd61e", "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(users).To(HaveLen(2))
+		Expect(filters).To(Equal([]string{`Id eq "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929" or Id eq "baf908c9-3248-451f-ab3c-103d921cd61e"`}))
+	})
+
 	Context("when UAA is not responding normally", func() {
 		BeforeEach(func() {
 			fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -152,4 +191,16 @@ var _ = Describe("UsersEmailsByIds", func() {
 			Expect(err.Error()).To(Equal(`UAA Failure: 401 {"errors": "Unauthorized"}`))
 		})
 	})
+
+	Describe("UsersEmailsQueryURIFromParts", func() {
+		It("joins the filters with or", func() {
+			uri := uaa.UsersEmailsQueryURIFromParts("http://uaa.example.com", []string{`Id eq "1234"`, `Id eq "5678"`})
+
+			parsed, err := url.Parse(uri)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed.Path).To(Equal("/Users"))
+			Expect(parsed.Query().Get("attributes")).To(Equal("emails,id"))
+			Expect(parsed.Query().Get("filter")).To(Equal(`Id eq "1234" or Id eq "5678"`))
+		})
+	})
 })
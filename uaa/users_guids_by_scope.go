@@ -1,62 +1,107 @@
 package uaa
 
-import (
-	"encoding/json"
-	"fmt"
-	"net/url"
-)
+import "fmt"
 
 type UsersGUIDsByScopeInterface interface {
 	UsersGUIDsByScope(string) ([]string, error)
 }
 
-type userGUIDsByScopeResponse struct {
-	Resources []struct {
-		Members []struct {
-			GUID string `json:"value"`
-		} `json:"members"`
-	} `json:"resources"`
+type GroupMembersByScopeInterface interface {
+	GroupMembersByScope(string) ([]GroupMember, error)
 }
 
-func UsersGUIDsByScope(u UAA, scope string) ([]string, error) {
-	var guids []string
-	filterValue := url.QueryEscape("displayName eq \"" + scope + "\"")
+// Returned when no group grants the scope. It matches NotFoundError, so
+// IsNotFound reports it too.
+type GroupNotFoundError struct {
+	Scope string
+}
 
-	uri, err := url.Parse(fmt.Sprintf("%s/Groups?attributes=members&filter=%s", u.uaaURL, filterValue))
-	if err != nil {
-		return guids, err
-	}
+func (err GroupNotFoundError) Error() string {
+	return fmt.Sprintf("UAA group not found: %s", err.Scope)
+}
+
+func (err GroupNotFoundError) Is(target error) bool {
+	return target == NotFoundError
+}
 
-	host := uri.Scheme + "://" + uri.Host
-	client := NewClient(host, u.VerifySSL).WithAuthorizationToken(u.AccessToken)
-	code, body, err := client.MakeRequest("GET", uri.RequestURI(), nil)
+// Returns the GUIDs of the users holding the scope, directly or through
+// nested groups at any depth. Each user is listed once, and groups nested in
+// each other are only followed once.
+func UsersGUIDsByScope(u UAA, scope string) (_ []string, err error) {
+	u, span := u.withOperation("UsersGUIDsByScope")
+	defer span.end(&err)
+
+	guids := []string{}
+
+	group, err := groupByScope(u, scope)
 	if err != nil {
 		return guids, err
 	}
 
-	if code > 399 {
-		return guids, NewFailure(code, body)
+	seenUsers := map[string]bool{}
+	seenGroups := map[string]bool{group.ID: true}
+
+	pending := [][]GroupMember{group.Members}
+	for len(pending) != 0 {
+		members := pending[0]
+		pending = pending[1:]
+
+		for _, member := range members {
+			if member.Type != GroupMemberGroup {
+				if !seenUsers[member.Value] {
+					seenUsers[member.Value] = true
+					guids = append(guids, member.Value)
+				}
+				continue
+			}
+
+			if seenGroups[member.Value] {
+				continue
+			}
+			seenGroups[member.Value] = true
+
+			nested, err := GroupMembers(u, member.Value)
+			if err != nil {
+				return []string{}, err
+			}
+			pending = append(pending, nested)
+		}
 	}
 
-	guids, err = guidsFromBody(body)
+	return guids, nil
+}
+
+// Returns the direct members of the group granting the scope, users and
+// nested groups alike, as UsersGUIDsByScope finds them
+func GroupMembersByScope(u UAA, scope string) (_ []GroupMember, err error) {
+	u, span := u.withOperation("GroupMembersByScope")
+	defer span.end(&err)
+
+	group, err := groupByScope(u, scope)
 	if err != nil {
-		return guids, err
+		return []GroupMember{}, err
 	}
 
-	return guids, nil
+	if group.Members == nil {
+		return []GroupMember{}, nil
+	}
+	return group.Members, nil
 }
 
-func guidsFromBody(body []byte) ([]string, error) {
-	var response userGUIDsByScopeResponse
-	guids := []string{}
-	err := json.Unmarshal(body, &response)
+// Finds the group whose display name is the scope, with only its ID and
+// members
+func groupByScope(u UAA, scope string) (Group, error) {
+	query := listQuery{Filter: Eq("displayName", scope), Attributes: []string{"id", "members"}}
+
+	groups := []Group{}
+	_, err := u.listRequest("/Groups", query.values(), &groups)
 	if err != nil {
-		return guids, err
+		return Group{}, err
 	}
 
-	for _, member := range response.Resources[0].Members {
-		guids = append(guids, member.GUID)
+	if len(groups) == 0 {
+		return Group{}, GroupNotFoundError{Scope: scope}
 	}
 
-	return guids, nil
+	return groups[0], nil
 }
@@ -60,7 +60,7 @@ var _ = Describe("UsersGUIDsByScope", func() {
 					panic(err)
 				}
 
-				if req.Form.Get("attributes") != "members" {
+				if req.Form.Get("attributes") != "id,members" {
 					w.WriteHeader(http.StatusOK)
 					w.Write([]byte{})
 					return
@@ -110,6 +110,102 @@ var _ = Describe("UsersGUIDsByScope", func() {
 		})
 	})
 
+	Context("when no group grants the scope", func() {
+		It("returns a GroupNotFoundError", func() {
+			users = []map[string][]map[string]string{}
+
+			guids, err := uaa.UsersGUIDsByScope(auth, "this.scope")
+
+			Expect(err).To(Equal(uaa.GroupNotFoundError{Scope: "this.scope"}))
+			Expect(err.Error()).To(Equal("UAA group not found: this.scope"))
+			Expect(uaa.IsNotFound(err)).To(BeTrue())
+			Expect(guids).To(Equal([]string{}))
+		})
+	})
+
+	Context("when groups are nested", func() {
+		var memberRequests []string
+
+		BeforeEach(func() {
+			memberRequests = []string{}
+			fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch req.URL.Path {
+				case "/Groups":
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(`{
+                        "resources": [{
+                            "id": "root-group",
+                            "members": [
+                                {"value": "user-1", "type": "USER", "origin": "uaa"},
+                                {"value": "nested-group", "type": "GROUP", "origin": "uaa"}
+                            ]
+                        }],
+                        "totalResults": 1
+                    }`))
+				case "/Groups/nested-group/members":
+					memberRequests = append(memberRequests, req.URL.Path)
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(`[
+                        {"value": "user-2", "type": "USER", "origin": "ldap"},
+                        {"value": "user-1", "type": "USER", "origin": "uaa"},
+                        {"value": "deeper-group", "type": "GROUP", "origin": "uaa"}
+                    ]`))
+				case "/Groups/deeper-group/members":
+					memberRequests = append(memberRequests, req.URL.Path)
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(`[
+                        {"value": "user-3", "type": "USER", "origin": "uaa"},
+                        {"value": "root-group", "type": "GROUP", "origin": "uaa"},
+                        {"value": "nested-group", "type": "GROUP", "origin": "uaa"}
+                    ]`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"error":"scim_resource_not_found","error_description":"Group does not exist"}`))
+				}
+			}))
+			auth = uaa.NewUAA("http://uaa.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+		})
+
+		AfterEach(func() {
+			fakeUAAServer.Close()
+		})
+
+		It("returns the direct members with their types", func() {
+			members, err := uaa.GroupMembersByScope(auth, "this.scope")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(members).To(Equal([]uaa.GroupMember{
+				{Value: "user-1", Type: uaa.GroupMemberUser, Origin: "uaa"},
+				{Value: "nested-group", Type: uaa.GroupMemberGroup, Origin: "uaa"},
+			}))
+		})
+
+		It("returns an error when a nested group cannot be read", func() {
+			fakeUAAServer.Config.Handler = wrapHandler(fakeUAAServer.Config.Handler, func(req *http.Request) {
+				if req.URL.Path == "/Groups/deeper-group/members" {
+					req.URL.Path = "/Groups/missing-group/members"
+				}
+			})
+
+			guids, err := uaa.UsersGUIDsByScope(auth, "this.scope")
+
+			Expect(uaa.IsNotFound(err)).To(BeTrue())
+			Expect(err).To(BeAssignableToTypeOf(uaa.Failure{}))
+			Expect(guids).To(Equal([]string{}))
+		})
+
+		It("resolves the users of nested groups once, without following cycles", func() {
+			guids, err := uaa.UsersGUIDsByScope(auth, "this.scope")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(guids).To(Equal([]string{"user-1", "user-2", "user-3"}))
+			Expect(memberRequests).To(Equal([]string{
+				"/Groups/nested-group/members",
+				"/Groups/deeper-group/members",
+			}))
+		})
+	})
+
 	Context("when UAA is not responding normally", func() {
 		BeforeEach(func() {
 			fakeUAAServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
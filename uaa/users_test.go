@@ -0,0 +1,240 @@
+package uaa_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/pivotal-cf/uaa-sso-golang/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const createdUserJSON = `{
+  "id": "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929",
+  "meta": {
+    "version": 0,
+    "created": "2014-05-22T22:36:36.941Z",
+    "lastModified": "2014-05-22T22:36:36.941Z"
+  },
+  "userName": "jane",
+  "name": {
+    "familyName": "Doe",
+    "givenName": "Jane"
+  },
+  "emails": [
+    {
+      "value": "jane@example.com"
+    }
+  ],
+  "active": true,
+  "verified": false,
+  "schemas": [
+    "urn:scim:schemas:core:1.0"
+  ]
+}`
+
+var _ = Describe("User management", func() {
+	var fakeUAAServer *httptest.Server
+	var auth uaa.UAA
+	var requests []*http.Request
+	var requestBodies []string
+
+	BeforeEach(func() {
+		requests = []*http.Request{}
+		requestBodies = []string{}
+		fakeUAAServer = httptest.NewServer(recordRequests(&requests, &requestBodies, func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "Bearer my-special-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			switch {
+			case req.URL.Path == "/Users" && req.Method == "POST":
+				var user map[string]interface{}
+				json.NewDecoder(req.Body).Decode(&user)
+				if user["userName"] == "taken" {
+					w.WriteHeader(http.StatusConflict)
+					w.Write([]byte(`{"error":"scim_resource_already_exists","error_description":"Username already in use: taken","message":"Username already in use: taken"}`))
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(createdUserJSON))
+			case req.URL.Path == "/Users/87dfc5b4-daf9-49fd-9aa8-bb1e21d28929":
+				ifMatch := req.Header.Get("If-Match")
+				if req.Method != "DELETE" && ifMatch != "*" && ifMatch != "0" {
+					w.WriteHeader(http.StatusConflict)
+					w.Write([]byte(`{"error":"scim_resource_conflict","error_description":"Version mismatch"}`))
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(createdUserJSON))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":"scim_resource_not_found","error_description":"User does not exist"}`))
+			}
+		}))
+		auth = uaa.NewUAA("http://login.example.com", fakeUAAServer.URL, "the-client-id", "the-client-secret", "my-special-token")
+	})
+
+	AfterEach(func() {
+		fakeUAAServer.Close()
+	})
+
+	Describe("CreateUser", func() {
+		It("creates the user and returns it as stored by UAA", func() {
+			user, err := uaa.CreateUser(auth, uaa.User{
+				Username: "jane",
+				Password: "secret",
+				Name:     uaa.Name{FamilyName: "Doe", GivenName: "Jane"},
+				Emails:   []string{"jane@example.com"},
+				Active:   true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Method).To(Equal("POST"))
+			Expect(requests[0].Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(requestBodies[0]).To(MatchJSON(`{
+                "schemas": ["urn:scim:schemas:core:1.0"],
+                "userName": "jane",
+                "password": "secret",
+                "name": {"familyName": "Doe", "givenName": "Jane"},
+                "emails": [{"value": "jane@example.com"}],
+                "active": true,
+                "verified": false
+            }`))
+
+			Expect(user.ID).To(Equal("87dfc5b4-daf9-49fd-9aa8-bb1e21d28929"))
+			Expect(user.Meta.Version).To(Equal(0))
+			Expect(user.Meta.Created).To(Equal(time.Date(2014, 5, 22, 22, 36, 36, 941000000, time.UTC)))
+		})
+
+		It("creates active users whatever Active is set to", func() {
+			_, err := uaa.CreateUser(auth, uaa.User{Username: "jane", Password: "secret"})
+			Expect(err).NotTo(HaveOccurred())
+
+			var sent map[string]interface{}
+			Expect(json.Unmarshal([]byte(requestBodies[0]), &sent)).To(Succeed())
+			Expect(sent["active"]).To(Equal(true))
+		})
+
+		It("returns a conflict when the username is taken", func() {
+			_, err := uaa.CreateUser(auth, uaa.User{Username: "taken"})
+
+			Expect(uaa.IsConflict(err)).To(BeTrue())
+			Expect(err.(uaa.Failure).ErrorDescription()).To(Equal("Username already in use: taken"))
+		})
+	})
+
+	Describe("UpdateUser", func() {
+		It("updates users at the version they were read at", func() {
+			user, err := uaa.CreateUser(auth, uaa.User{Username: "jane", Password: "secret"})
+			Expect(err).NotTo(HaveOccurred())
+
+			user.Password = "ignored"
+			user.Name.GivenName = "Janet"
+			_, err = uaa.UpdateUser(auth, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[1].Method).To(Equal("PUT"))
+			Expect(requests[1].URL.Path).To(Equal("/Users/87dfc5b4-daf9-49fd-9aa8-bb1e21d28929"))
+			Expect(requests[1].Header.Get("If-Match")).To(Equal("0"))
+			Expect(requestBodies[1]).To(ContainSubstring(`"givenName":"Janet"`))
+			Expect(requestBodies[1]).NotTo(ContainSubstring("password"))
+		})
+
+		It("returns a conflict when the version does not match", func() {
+			user, err := uaa.CreateUser(auth, uaa.User{Username: "jane"})
+			Expect(err).NotTo(HaveOccurred())
+
+			user.Meta.Version = 3
+			_, err = uaa.UpdateUser(auth, user)
+
+			Expect(uaa.IsConflict(err)).To(BeTrue())
+			Expect(err.(uaa.Failure).ErrorDescription()).To(Equal("Version mismatch"))
+		})
+
+		It("refuses to update users that were not read from UAA", func() {
+			_, err := uaa.UpdateUser(auth, uaa.User{ID: "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", Username: "jane"})
+
+			Expect(err).To(Equal(uaa.MissingVersionError))
+			Expect(requests).To(BeEmpty())
+		})
+
+		It("updates users whatever their version with AnyVersion", func() {
+			_, err := uaa.UpdateUser(auth, uaa.User{
+				ID:       "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929",
+				Username: "jane",
+				Meta:     uaa.Meta{Version: uaa.AnyVersion},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Header.Get("If-Match")).To(Equal("*"))
+			Expect(requestBodies[0]).NotTo(ContainSubstring("meta"))
+		})
+	})
+
+	Describe("PatchUser", func() {
+		It("sends only the given attributes", func() {
+			user, err := uaa.PatchUser(auth, "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", 0, map[string]interface{}{
+				"name": map[string]string{"givenName": "Janet"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Method).To(Equal("PATCH"))
+			Expect(requests[0].Header.Get("If-Match")).To(Equal("0"))
+			Expect(requestBodies[0]).To(MatchJSON(`{"name":{"givenName":"Janet"}}`))
+			Expect(user.Username).To(Equal("jane"))
+		})
+
+		It("patches whatever the version with AnyVersion", func() {
+			_, err := uaa.PatchUser(auth, "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929", uaa.AnyVersion, map[string]interface{}{"active": false})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Header.Get("If-Match")).To(Equal("*"))
+		})
+	})
+
+	Describe("DeleteUser", func() {
+		It("deletes the user", func() {
+			err := uaa.DeleteUser(auth, "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].Method).To(Equal("DELETE"))
+		})
+
+		It("returns an error for unknown users", func() {
+			err := uaa.DeleteUser(auth, "unknown")
+
+			Expect(uaa.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Describe("User JSON", func() {
+		It("round trips every field", func() {
+			user := uaa.User{
+				Username: "jane",
+				ID:       "87dfc5b4-daf9-49fd-9aa8-bb1e21d28929",
+				Name:     uaa.Name{FamilyName: "Doe", GivenName: "Jane"},
+				Emails:   []string{"jane@example.com", "jane.doe@example.com"},
+				Active:   true,
+				Verified: true,
+				Meta: uaa.Meta{
+					Version:      4,
+					Created:      time.Date(2014, 5, 22, 22, 36, 36, 941000000, time.UTC),
+					LastModified: time.Date(2014, 6, 25, 23, 10, 3, 845000000, time.UTC),
+				},
+				Password: "secret",
+			}
+
+			encoded, err := json.Marshal(user)
+			Expect(err).NotTo(HaveOccurred())
+
+			var decoded uaa.User
+			Expect(json.Unmarshal(encoded, &decoded)).To(Succeed())
+			Expect(decoded).To(Equal(user))
+		})
+	})
+})